@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+
+	"github.com/thinesjs/gha-freeze/internal/config"
+	"github.com/thinesjs/gha-freeze/internal/github"
+)
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage the GitHub token used by gha-freeze",
+	Long: `Manage the GitHub Personal Access Token used by gha-freeze.
+
+Tokens are looked up in this order: --token flag, GITHUB_TOKEN,
+GHA_FREEZE_TOKEN, the token file stored by 'gha-freeze auth login'
+(~/.config/gha-freeze/token, 0600 permissions), then the GitHub CLI's
+hosts.yml (respecting GH_CONFIG_DIR and GH_HOST). A GitHub Enterprise Server
+--host that GH_HOST does not name only gets its own hosts.yml entry.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAuth,
+}
+
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Save a GitHub token read from stdin or a hidden prompt",
+	Long: `Save a GitHub token for automatic use in future commands.
+
+When stdin is a terminal the token is read from a hidden prompt, otherwise it
+is read from stdin:
+
+  gha-freeze auth login < token.txt
+  echo "$TOKEN" | gha-freeze auth login`,
+	Args: cobra.NoArgs,
+	RunE: runAuthLogin,
+}
+
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which token is in use and its remaining quota",
+	Args:  cobra.NoArgs,
+	RunE:  runAuthStatus,
+}
+
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Delete the stored GitHub token",
+	Args:  cobra.NoArgs,
+	RunE:  runAuthLogout,
+}
+
+func init() {
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+}
+
+func runAuth(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Help()
+	}
+
+	fmt.Fprintf(os.Stderr, "Warning: passing the token as an argument leaves it in your shell history.\n")
+	fmt.Fprintf(os.Stderr, "Use 'gha-freeze auth login' instead.\n\n")
+	return saveValidatedToken(args[0])
+}
+
+func runAuthLogin(cmd *cobra.Command, args []string) error {
+	tok, err := readToken(cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+	return saveValidatedToken(tok)
+}
+
+func readToken(in io.Reader) (string, error) {
+	if f, ok := in.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		fmt.Fprint(os.Stderr, "Paste your GitHub token: ")
+		data, err := term.ReadPassword(f.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("failed to read token from stdin: %w", err)
+	}
+
+	tok := strings.TrimSpace(line)
+	if tok == "" {
+		return "", fmt.Errorf("no token provided")
+	}
+	return tok, nil
+}
+
+func saveValidatedToken(tok string) error {
+	tok = strings.TrimSpace(tok)
+	if tok == "" {
+		return fmt.Errorf("no token provided")
+	}
+
+	client, err := authClient()
+	if err != nil {
+		return err
+	}
+	info, err := client.ValidateToken(tok)
+	if err != nil {
+		return fmt.Errorf("token validation failed: %w", err)
+	}
+
+	if err := config.SaveToken(tok); err != nil {
+		return fmt.Errorf("failed to save token: %w", err)
+	}
+
+	tokenPath, _ := config.GetTokenPath()
+	fmt.Printf("✓ Logged in as %s\n", info.Login)
+	fmt.Printf("✓ Token saved to %s\n", tokenPath)
+	fmt.Printf("The token will be used automatically for future commands.\n")
+	return nil
+}
+
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	creds, err := config.GetAppCredentials(appID, appInstallID, appKeyPath)
+	if err != nil {
+		return err
+	}
+	if creds != nil {
+		fmt.Printf("Token source: GitHub App %d (installation %d)\n", creds.AppID, creds.InstallationID)
+		client, err := newGitHubClient()
+		if err != nil {
+			return err
+		}
+		status, err := client.CheckRateLimit()
+		if err != nil {
+			return fmt.Errorf("failed to obtain installation token: %w", err)
+		}
+		fmt.Printf("Rate limit:   %d/%d remaining\n", status.Remaining, status.Limit)
+		return nil
+	}
+
+	f, err := authForge()
+	if err != nil {
+		return err
+	}
+	tok, source := config.ResolveTokenForHost(token, f.tokenHost())
+	tokenPath, _ := config.GetTokenPath()
+	client := github.NewClient("", github.WithBaseURL(f.apiURL()))
+
+	if source == config.SourceNone {
+		fmt.Printf("Not authenticated\n")
+		fmt.Printf("Stored token: none (%s)\n", tokenPath)
+
+		status, err := client.CheckRateLimit()
+		if err != nil {
+			return fmt.Errorf("failed to check rate limit: %w", err)
+		}
+		fmt.Printf("Rate limit:   %d/%d remaining (unauthenticated)\n", status.Remaining, status.Limit)
+		fmt.Printf("\nRun 'gha-freeze auth login' to raise the limit to 5,000 requests/hour.\n")
+		return nil
+	}
+
+	fmt.Printf("Token source: %s\n", source)
+	switch source {
+	case config.SourceFile:
+		fmt.Printf("Token file:   %s\n", tokenPath)
+	case config.SourceGHCLI:
+		fmt.Printf("Token file:   %s\n", filepath.Join(config.GHConfigDir(), "hosts.yml"))
+	}
+
+	info, err := client.ValidateToken(tok)
+	if err != nil {
+		fmt.Printf("Status:       could not validate token (%v)\n", err)
+		return fmt.Errorf("token from %s could not be validated", source)
+	}
+
+	scopes := "(none)"
+	if len(info.Scopes) > 0 {
+		scopes = strings.Join(info.Scopes, ", ")
+	}
+
+	fmt.Printf("Logged in as: %s\n", info.Login)
+	fmt.Printf("Scopes:       %s\n", scopes)
+	fmt.Printf("Rate limit:   %d/%d remaining (resets %s)\n",
+		info.Remaining, info.Limit, info.Reset.Local().Format(time.Kitchen))
+	return nil
+}
+
+// authClient is an unauthenticated client for the configured GitHub or GitHub
+// Enterprise Server host, against which tokens are validated.
+func authClient() (*github.Client, error) {
+	f, err := authForge()
+	if err != nil {
+		return nil, err
+	}
+	return github.NewClient("", github.WithBaseURL(f.apiURL())), nil
+}
+
+// authForge is the configured host, which must be GitHub or GitHub
+// Enterprise Server.
+func authForge() (forge, error) {
+	f, err := resolveForge()
+	if err != nil {
+		return forge{}, err
+	}
+	if f.gitea {
+		return forge{}, fmt.Errorf("auth manages the GitHub token, but %s is a Gitea or Forgejo host: set GITEA_TOKEN instead", f.host)
+	}
+	return f, nil
+}
+
+func runAuthLogout(cmd *cobra.Command, args []string) error {
+	deleted, err := config.DeleteToken()
+	if err != nil {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+
+	tokenPath, _ := config.GetTokenPath()
+	if deleted {
+		fmt.Printf("✓ Deleted stored token %s\n", tokenPath)
+	} else {
+		fmt.Printf("No stored token found at %s\n", tokenPath)
+	}
+
+	for _, env := range []string{"GITHUB_TOKEN", "GHA_FREEZE_TOKEN"} {
+		if os.Getenv(env) != "" {
+			fmt.Printf("Note: %s is still set and will continue to be used.\n", env)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thinesjs/gha-freeze/internal/backup"
+	"github.com/thinesjs/gha-freeze/internal/diff"
+)
+
+var (
+	pruneKeep      int
+	pruneOlderThan string
+	pruneDryRun    bool
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Inspect, restore and prune workflow backups",
+	Long: `Inspect, restore and prune the backups gha-freeze creates before modifying
+workflow files. BACKUP is a backup timestamp (e.g. 20250101-120000), its
+directory name or path, or "latest".`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available backups",
+	Args:  cobra.NoArgs,
+	RunE:  runBackupList,
+}
+
+var backupShowCmd = &cobra.Command{
+	Use:   "show BACKUP",
+	Short: "Show the files recorded in a backup",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runBackupShow,
+}
+
+var backupDiffCmd = &cobra.Command{
+	Use:   "diff BACKUP [files...]",
+	Short: "Show a unified diff between a backup and the current files",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runBackupDiff,
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore BACKUP [files...]",
+	Short: "Restore all or specific files from a backup",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runBackupRestore,
+}
+
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old backups",
+	Long: `Delete old backups. A backup is kept if it is among the newest --keep
+backups or younger than --older-than; everything else is deleted.`,
+	Args: cobra.NoArgs,
+	RunE: runBackupPrune,
+}
+
+func init() {
+	backupPruneCmd.Flags().IntVar(&pruneKeep, "keep", 0, "Number of most recent backups to keep")
+	backupPruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "Only delete backups older than this age (e.g. 30d, 2w, 12h)")
+	backupPruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show which backups would be deleted")
+
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupShowCmd)
+	backupCmd.AddCommand(backupDiffCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	backupCmd.AddCommand(backupPruneCmd)
+}
+
+func runBackupList(cmd *cobra.Command, args []string) error {
+	root, err := repoRoot()
+	if err != nil {
+		return err
+	}
+
+	backups, err := backup.ListBackups(root)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) == 0 {
+		fmt.Printf("No backups found\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "BACKUP\tFILES\tVERSION\tLOCATION\tPATH\n")
+	for i := len(backups) - 1; i >= 0; i-- {
+		b := backups[i]
+		ver := "-"
+		if b.Manifest != nil && b.Manifest.Version != "" {
+			ver = b.Manifest.Version
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", b.Timestamp, b.FileCount, ver, b.Location, displayPath(root, b.Path))
+	}
+	return w.Flush()
+}
+
+func runBackupShow(cmd *cobra.Command, args []string) error {
+	root, err := repoRoot()
+	if err != nil {
+		return err
+	}
+
+	b, err := backup.FindBackup(root, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Backup:  %s\n", b.Timestamp)
+	fmt.Printf("Path:    %s (%s)\n", displayPath(root, b.Path), b.Location)
+	if created := b.CreatedAt(); !created.IsZero() {
+		fmt.Printf("Created: %s\n", created.Local().Format(time.RFC1123))
+	}
+
+	if b.Manifest == nil {
+		fmt.Printf("Format:  legacy (no manifest)\n\n")
+		files, err := b.Files()
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			fmt.Printf("  %s\n", f.Path)
+		}
+		return nil
+	}
+
+	if b.Manifest.Version != "" {
+		fmt.Printf("Version: %s\n", b.Manifest.Version)
+	}
+
+	integrity := "ok"
+	if err := backup.VerifyBackup(b); err != nil {
+		integrity = err.Error()
+	}
+	fmt.Printf("Integrity: %s\n\n", integrity)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "  FILE\tMODE\tSHA256\n")
+	for _, f := range b.Manifest.Files {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", f.Path, f.Mode, f.SHA256)
+	}
+	return w.Flush()
+}
+
+func runBackupDiff(cmd *cobra.Command, args []string) error {
+	root, err := repoRoot()
+	if err != nil {
+		return err
+	}
+
+	b, err := backup.FindBackup(root, args[0])
+	if err != nil {
+		return err
+	}
+
+	files, err := b.SelectFiles(root, args[1:])
+	if err != nil {
+		return err
+	}
+
+	changed := 0
+	for _, f := range files {
+		saved, err := os.ReadFile(f.Source)
+		if err != nil {
+			return fmt.Errorf("failed to read %s from backup: %w", f.Path, err)
+		}
+
+		current, err := os.ReadFile(filepath.Join(root, f.Path))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %w", f.Path, err)
+		}
+
+		newName := "b/" + f.Path
+		if os.IsNotExist(err) {
+			newName = "/dev/null"
+		}
+
+		if d := diff.Unified("a/"+f.Path, newName, saved, current, diff.DefaultContext); d != "" {
+			fmt.Printf("diff --git a/%s b/%s\n%s", f.Path, f.Path, d)
+			changed++
+		}
+	}
+
+	if changed == 0 {
+		fmt.Fprintf(os.Stderr, "No differences between backup %s and the current files\n", b.Timestamp)
+	}
+	return nil
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) error {
+	root, err := repoRoot()
+	if err != nil {
+		return err
+	}
+
+	b, err := backup.FindBackup(root, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := backup.RestoreFiles(root, b.Path, args[1:]); err != nil {
+		return err
+	}
+
+	if len(args) > 1 {
+		fmt.Printf("✓ Restored %d files from backup %s\n", len(args)-1, b.Timestamp)
+	} else {
+		fmt.Printf("✓ Restored %d files from backup %s\n", b.FileCount, b.Timestamp)
+	}
+	return nil
+}
+
+func runBackupPrune(cmd *cobra.Command, args []string) error {
+	if pruneKeep <= 0 && pruneOlderThan == "" {
+		return fmt.Errorf("specify --keep, --older-than, or both")
+	}
+
+	var cutoff time.Time
+	if pruneOlderThan != "" {
+		age, err := parseAge(pruneOlderThan)
+		if err != nil {
+			return err
+		}
+		cutoff = time.Now().Add(-age)
+	}
+
+	root, err := repoRoot()
+	if err != nil {
+		return err
+	}
+
+	backups, err := backup.ListBackups(root)
+	if err != nil {
+		return err
+	}
+
+	pruned := 0
+	for i, b := range backups {
+		newestIndex := len(backups) - 1 - i
+		if pruneKeep > 0 && newestIndex < pruneKeep {
+			continue
+		}
+		if !cutoff.IsZero() && b.CreatedAt().After(cutoff) {
+			continue
+		}
+
+		if pruneDryRun {
+			fmt.Printf("Would delete %s\n", displayPath(root, b.Path))
+		} else {
+			if err := backup.DeleteBackup(b.Path); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted %s\n", displayPath(root, b.Path))
+		}
+		pruned++
+	}
+
+	if pruned == 0 {
+		fmt.Printf("Nothing to prune\n")
+	}
+	return nil
+}
+
+// displayPath shows backups inside the repository relative to its root.
+func displayPath(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q: use e.g. 30d, 2w or 12h", s)
+	}
+	return d, nil
+}
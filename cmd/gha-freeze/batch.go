@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/thinesjs/gha-freeze/internal/backup"
+	"github.com/thinesjs/gha-freeze/internal/config"
+	"github.com/thinesjs/gha-freeze/internal/git"
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/pkg/freeze"
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch REPO...",
+	Short: "Pin actions in several repositories without the interactive UI",
+	Long: `Pin every unpinned action in each given repository. A REPO can be any
+directory inside a repository. Resolutions are shared between repositories, so
+an action used in many of them is only looked up once. A summary table is
+printed at the end.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runBatch,
+}
+
+func init() {
+	batchCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Report what would change without modifying files")
+	batchCmd.Flags().BoolVar(&noBackup, "no-backup", false, "Skip creating backup files")
+	batchCmd.Flags().StringVar(&backupLoc, "backup-location", "", "Where to store backups: repo (.github/workflows) or state (XDG state dir)")
+	batchCmd.Flags().StringArrayVar(&workflowDirOpt, "workflow-dir", nil, "Workflow directory to scan in each repository (repeatable; default: detect)")
+}
+
+type batchResult struct {
+	repo     string
+	unpinned int
+	pinned   int
+	failed   int
+	files    int
+	status   string
+	err      error
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	if repoDir != "" {
+		return fmt.Errorf("--repo cannot be combined with batch; list the repositories as arguments")
+	}
+
+	f, err := newFreezer()
+	if err != nil {
+		return err
+	}
+
+	loc, err := resolveBackupLocation()
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var results []batchResult
+	seen := make(map[string]bool)
+	for _, path := range args {
+		root, err := git.FindRoot(path)
+		if err != nil {
+			results = append(results, batchResult{repo: path, status: "error", err: err})
+			continue
+		}
+		if seen[root] {
+			continue
+		}
+		seen[root] = true
+
+		results = append(results, pinRepo(ctx, f, root, loc))
+	}
+
+	if err := printBatchSummary(results); err != nil {
+		return err
+	}
+
+	var failedRepos int
+	for _, r := range results {
+		if r.err != nil || r.failed > 0 {
+			failedRepos++
+		}
+	}
+	if failedRepos > 0 {
+		return fmt.Errorf("%d of %d repositories had errors or unresolved actions", failedRepos, len(results))
+	}
+	return nil
+}
+
+func pinRepo(ctx context.Context, f *freeze.Freezer, root string, loc backup.Location) batchResult {
+	result := batchResult{repo: root}
+	fail := func(err error) batchResult {
+		result.status = "error"
+		result.err = err
+		return result
+	}
+
+	fsys := os.DirFS(root)
+	refs, err := f.Scan(ctx, fsys)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			result.status = "no workflows"
+			return result
+		}
+		return fail(err)
+	}
+
+	var unpinned []freeze.Reference
+	for _, r := range refs {
+		if !r.Pinned {
+			unpinned = append(unpinned, r)
+		}
+	}
+	result.unpinned = len(unpinned)
+
+	resolutions, err := f.Resolve(ctx, unpinned)
+	if err != nil {
+		return fail(err)
+	}
+
+	for _, r := range resolutions {
+		if r.Err != nil {
+			result.failed++
+			fmt.Fprintf(os.Stderr, "✗ %s: %s (%s:%d): %s\n",
+				root, r.Reference.Action(), r.Reference.Path, r.Reference.Line, r.Reason)
+			continue
+		}
+		result.pinned++
+	}
+
+	edits, err := f.Plan(ctx, fsys, resolutions)
+	if err != nil {
+		return fail(err)
+	}
+	result.files = len(edits)
+
+	switch {
+	case len(edits) == 0 && result.failed == 0:
+		result.status = "up to date"
+		return result
+	case len(edits) == 0:
+		result.status = "unresolved"
+		return result
+	case dryRun:
+		result.status = "would pin"
+		return result
+	}
+
+	if !noBackup {
+		files := make([]string, len(edits))
+		for i, e := range edits {
+			files[i] = filepath.FromSlash(e.Path)
+		}
+		if _, err := backup.CreateBackup(root, files, version, loc); err != nil {
+			return fail(fmt.Errorf("failed to create backup: %w", err))
+		}
+	}
+
+	if err := f.Apply(ctx, freeze.DirWriter(root), edits); err != nil {
+		return fail(err)
+	}
+
+	result.status = "pinned"
+	if result.failed > 0 {
+		result.status = "partially pinned"
+	}
+	return result
+}
+
+func printBatchSummary(results []batchResult) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "REPO\tUNPINNED\tPINNED\tFAILED\tFILES\tSTATUS\n")
+	for _, r := range results {
+		status := r.status
+		if r.err != nil {
+			status = "error: " + r.err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\n", r.repo, r.unpinned, r.pinned, r.failed, r.files, status)
+	}
+	return w.Flush()
+}
+
+// newFreezer builds a library client with the same host, credentials and
+// workflow directories as the interactive mode.
+func newFreezer() (*freeze.Freezer, error) {
+	dirs, err := configuredWorkflowDirs()
+	if err != nil {
+		return nil, err
+	}
+	for i, d := range dirs {
+		dirs[i] = filepath.ToSlash(d)
+	}
+	opts := []freeze.Option{freeze.WithWorkflowDirs(dirs...)}
+
+	useGit, err := gitRefsEnabled()
+	if err != nil {
+		return nil, err
+	}
+	if useGit {
+		opts = append(opts, freeze.WithGitRefs(""))
+	}
+
+	f, err := resolveForge()
+	if err != nil {
+		return nil, err
+	}
+	if f.gitea {
+		return freeze.New(append(opts, freeze.WithGitea(f.host), freeze.WithToken(giteaToken()))...), nil
+	}
+	opts = append(opts, freeze.WithHost(f.host))
+
+	creds, err := config.GetAppCredentials(appID, appInstallID, appKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if creds != nil {
+		transport, err := github.NewAppTransport(creds.AppID, creds.InstallationID, creds.PrivateKey, f.apiURL())
+		if err != nil {
+			return nil, err
+		}
+		return freeze.New(append(opts, freeze.WithHTTPClient(&http.Client{Transport: transport}))...), nil
+	}
+
+	return freeze.New(append(opts, freeze.WithToken(f.githubToken()))...), nil
+}
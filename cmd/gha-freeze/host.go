@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/config"
+	"github.com/thinesjs/gha-freeze/internal/gitea"
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/lsremote"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+const (
+	hostTypeAuto   = "auto"
+	hostTypeGitHub = "github"
+	hostTypeGitea  = "gitea"
+
+	resolverAPI = "api"
+	resolverGit = "git"
+)
+
+var (
+	hostName       string
+	hostType       string
+	workflowDirOpt []string
+	resolverOpt    string
+)
+
+// forge is the server actions are resolved against.
+type forge struct {
+	host  string // empty for github.com
+	gitea bool
+}
+
+// apiURL is the REST base URL for a GitHub Enterprise Server host, or empty
+// for github.com.
+func (f forge) apiURL() string {
+	switch {
+	case f.host == "":
+		return ""
+	case strings.Contains(f.host, "://"):
+		return f.host
+	default:
+		return "https://" + f.host + "/api/v3/"
+	}
+}
+
+// webURL is where the host serves repositories over git.
+func (f forge) webURL() string {
+	switch {
+	case f.host == "":
+		return lsremote.DefaultBaseURL
+	case strings.Contains(f.host, "://"):
+		u, err := url.Parse(f.host)
+		if err != nil {
+			return f.host
+		}
+		return u.Scheme + "://" + u.Host
+	default:
+		return "https://" + f.host
+	}
+}
+
+// tokenHost is the host name GitHub tokens for f are looked up under.
+func (f forge) tokenHost() string {
+	switch {
+	case f.host == "":
+		return "github.com"
+	case strings.Contains(f.host, "://"):
+		u, err := url.Parse(f.host)
+		if err != nil {
+			return ""
+		}
+		return u.Host
+	default:
+		return f.host
+	}
+}
+
+// githubToken is the token sent to the GitHub or GitHub Enterprise Server
+// host f. The --token flag, GITHUB_TOKEN and the saved token only go to
+// github.com (or GH_HOST); other hosts get their own gh CLI entry.
+func (f forge) githubToken() string {
+	tok, _ := config.ResolveTokenForHost(token, f.tokenHost())
+	return tok
+}
+
+// resolveForge works out the host from --host, GHA_FREEZE_HOST or the host
+// setting. With host_type auto, any host other than github.com that answers
+// the Gitea version endpoint is treated as Gitea or Forgejo and the rest as
+// GitHub Enterprise Server.
+func resolveForge() (forge, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return forge{}, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	host := hostName
+	if host == "" {
+		host = os.Getenv("GHA_FREEZE_HOST")
+	}
+	if host == "" {
+		host = settings.Host
+	}
+	host = strings.TrimSuffix(host, "/")
+	if host == "github.com" || host == "https://github.com" {
+		host = ""
+	}
+
+	kind := hostType
+	if kind == "" {
+		kind = settings.HostType
+	}
+	if kind == "" {
+		kind = hostTypeAuto
+	}
+
+	switch kind {
+	case hostTypeGitHub:
+		return forge{host: host}, nil
+	case hostTypeGitea:
+		if host == "" {
+			return forge{}, fmt.Errorf("host type gitea needs --host")
+		}
+		return forge{host: host, gitea: true}, nil
+	case hostTypeAuto:
+		if host == "" {
+			return forge{}, nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return forge{host: host, gitea: gitea.Detect(ctx, host, nil)}, nil
+	default:
+		return forge{}, fmt.Errorf("invalid host type %q: must be %s, %s or %s", kind, hostTypeAuto, hostTypeGitHub, hostTypeGitea)
+	}
+}
+
+// newResolveClient returns a client that resolves actions against the
+// configured host, which may be a Gitea or Forgejo instance.
+func newResolveClient() (*github.Client, error) {
+	f, err := resolveForge()
+	if err != nil {
+		return nil, err
+	}
+	useGit, err := gitRefsEnabled()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.gitea {
+		var r github.Resolver = gitea.NewResolver(f.host, giteaToken(), nil)
+		if useGit {
+			r = lsremote.NewResolver(f.webURL(), r, nil)
+		}
+		return github.NewClient("", github.WithResolver(r)), nil
+	}
+
+	client, err := newGitHubClientFor(f)
+	if err != nil {
+		return nil, err
+	}
+	if useGit {
+		client.SetResolver(lsremote.NewResolver(f.webURL(), github.ResolverFunc(client.ResolveREST), nil))
+	}
+	return client, nil
+}
+
+// gitRefsEnabled reports whether --resolver, GHA_FREEZE_RESOLVER or the
+// resolver setting asks for the git protocol resolver.
+func gitRefsEnabled() (bool, error) {
+	mode := resolverOpt
+	if mode == "" {
+		mode = os.Getenv("GHA_FREEZE_RESOLVER")
+	}
+	if mode == "" {
+		settings, err := config.LoadSettings()
+		if err != nil {
+			return false, fmt.Errorf("failed to load settings: %w", err)
+		}
+		mode = settings.Resolver
+	}
+
+	switch mode {
+	case "", resolverAPI:
+		return false, nil
+	case resolverGit:
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid resolver %q: must be %s or %s", mode, resolverAPI, resolverGit)
+	}
+}
+
+// requireGitHub fails for commands that need the GitHub API itself.
+func requireGitHub(what string) error {
+	f, err := resolveForge()
+	if err != nil {
+		return err
+	}
+	if f.gitea {
+		return fmt.Errorf("%s needs GitHub, but %s is a Gitea or Forgejo host", what, f.host)
+	}
+	return nil
+}
+
+// giteaToken is the token sent to a Gitea or Forgejo host. GitHub tokens,
+// including --token, are never sent there.
+func giteaToken() string {
+	if env := os.Getenv("GITEA_TOKEN"); env != "" {
+		return env
+	}
+	return os.Getenv("GHA_FREEZE_GITEA_TOKEN")
+}
+
+// configuredWorkflowDirs returns the directories from --workflow-dir or the
+// workflow_dirs setting, or nil to detect them.
+func configuredWorkflowDirs() ([]string, error) {
+	dirs := workflowDirOpt
+	if len(dirs) == 0 {
+		settings, err := config.LoadSettings()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load settings: %w", err)
+		}
+		dirs = settings.WorkflowDirs
+	}
+
+	cleaned := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		d = filepath.Clean(d)
+		if filepath.IsAbs(d) || d == ".." || strings.HasPrefix(d, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("workflow directory %q must be inside the repository", d)
+		}
+		cleaned = append(cleaned, d)
+	}
+	return cleaned, nil
+}
+
+// workflowDirs returns the configured workflow directories, or those of
+// workflow.DefaultDirs that exist in the repository at root.
+func workflowDirs(root string) ([]string, error) {
+	dirs, err := configuredWorkflowDirs()
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) > 0 {
+		for _, d := range dirs {
+			if info, err := os.Stat(filepath.Join(root, d)); err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("workflows directory not found: %s", d)
+			}
+		}
+		return dirs, nil
+	}
+
+	dirs = workflow.DetectDirs(root)
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no workflows directory found (looked for %s)", strings.Join(workflow.DefaultDirs, ", "))
+	}
+	return dirs, nil
+}
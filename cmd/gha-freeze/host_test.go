@@ -0,0 +1,136 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/thinesjs/gha-freeze/pkg/freeze"
+)
+
+// fakeGHES records the Authorization header of every request it gets.
+type fakeGHES struct {
+	mu   sync.Mutex
+	auth []string
+}
+
+func (f *fakeGHES) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	f.mu.Unlock()
+	http.NotFound(w, r)
+}
+
+// setFlags sets the persistent flags for the duration of the test.
+func setFlags(t *testing.T, host, kind, tok string) {
+	t.Helper()
+	oldHost, oldKind, oldToken := hostName, hostType, token
+	hostName, hostType, token = host, kind, tok
+	t.Cleanup(func() { hostName, hostType, token = oldHost, oldKind, oldToken })
+}
+
+// isolateTokens hides every token source except the ones a test sets.
+func isolateTokens(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("GH_CONFIG_DIR", filepath.Join(home, "gh"))
+	for _, env := range []string{
+		"GH_HOST", "GITHUB_TOKEN", "GHA_FREEZE_TOKEN", "GHA_FREEZE_HOST", "GITEA_TOKEN", "GHA_FREEZE_GITEA_TOKEN",
+		"GHA_FREEZE_APP_ID", "GHA_FREEZE_APP_INSTALLATION_ID", "GHA_FREEZE_APP_PRIVATE_KEY",
+	} {
+		t.Setenv(env, "")
+	}
+	return filepath.Join(home, "gh")
+}
+
+func TestGitHubTokenNotSentToEnterpriseServer(t *testing.T) {
+	ghDir := isolateTokens(t)
+	f := &fakeGHES{}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	t.Setenv("GITHUB_TOKEN", "github_com_token")
+	setFlags(t, srv.URL+"/api/v3", hostTypeGitHub, "flag_token")
+
+	check := func(t *testing.T, want string) {
+		t.Helper()
+		f.auth = nil
+
+		client, err := newGitHubClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = client.CheckRateLimit()
+
+		freezer, err := newFreezer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := freezer.Resolve(t.Context(), []freeze.Reference{{Owner: "actions", Repo: "checkout", Ref: "v4"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(f.auth) == 0 {
+			t.Fatal("no requests reached the server")
+		}
+		for _, got := range f.auth {
+			if got != want {
+				t.Errorf("server got Authorization %q, want %q", got, want)
+			}
+		}
+	}
+
+	t.Run("no token for the host", func(t *testing.T) {
+		check(t, "")
+	})
+
+	t.Run("gh CLI token for the host", func(t *testing.T) {
+		u, err := url.Parse(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(ghDir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		hosts := u.Host + ":\n    oauth_token: ghes_token\n"
+		if err := os.WriteFile(filepath.Join(ghDir, "hosts.yml"), []byte(hosts), 0600); err != nil {
+			t.Fatal(err)
+		}
+		check(t, "Bearer ghes_token")
+	})
+}
+
+func TestGiteaTokenIgnoresTokenFlag(t *testing.T) {
+	isolateTokens(t)
+	setFlags(t, "", "", "github_token")
+
+	if got := giteaToken(); got != "" {
+		t.Errorf("giteaToken() = %q with only --token set, want none", got)
+	}
+	t.Setenv("GHA_FREEZE_GITEA_TOKEN", "fallback")
+	if got := giteaToken(); got != "fallback" {
+		t.Errorf("giteaToken() = %q, want GHA_FREEZE_GITEA_TOKEN", got)
+	}
+	t.Setenv("GITEA_TOKEN", "gitea")
+	if got := giteaToken(); got != "gitea" {
+		t.Errorf("giteaToken() = %q, want GITEA_TOKEN", got)
+	}
+}
+
+func TestForgeTokenHost(t *testing.T) {
+	for host, want := range map[string]string{
+		"":                                "github.com",
+		"ghe.example.com":                 "ghe.example.com",
+		"https://ghe.example.com/api/v3/": "ghe.example.com",
+		"http://127.0.0.1:8080/api/v3":    "127.0.0.1:8080",
+	} {
+		if got := (forge{host: host}).tokenHost(); got != want {
+			t.Errorf("tokenHost(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -9,10 +10,11 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
 
+	"github.com/thinesjs/gha-freeze/internal/backup"
 	"github.com/thinesjs/gha-freeze/internal/config"
+	"github.com/thinesjs/gha-freeze/internal/git"
 	"github.com/thinesjs/gha-freeze/internal/github"
 	"github.com/thinesjs/gha-freeze/internal/tui"
-	"github.com/thinesjs/gha-freeze/internal/updater"
 )
 
 var (
@@ -22,6 +24,15 @@ var (
 	noBackup      bool
 	checkUpdate   bool
 	skipUpdateChk bool
+	appID         int64
+	appInstallID  int64
+	appKeyPath    string
+	backupLoc     string
+	gitBranch     string
+	gitCommit     bool
+	force         bool
+	diffOutput    string
+	repoDir       string
 )
 
 var rootCmd = &cobra.Command{
@@ -31,12 +42,13 @@ var rootCmd = &cobra.Command{
 SHA commits with version comments for security and reproducibility.
 
 It will:
-  1. Find all workflow files in .github/workflows
+  1. Find all workflow files in .github/workflows (or .gitea/.forgejo)
   2. Parse them for action references
-  3. Resolve versions to SHA commits via GitHub API
+  3. Resolve versions to SHA commits via the GitHub (or Gitea/Forgejo) API
   4. Create backups before modifying files
   5. Replace action references with SHA + version comments`,
-	RunE: run,
+	RunE:         run,
+	SilenceUsage: true,
 }
 
 var versionCmd = &cobra.Command{
@@ -47,45 +59,40 @@ var versionCmd = &cobra.Command{
 	},
 }
 
-var updateCmd = &cobra.Command{
-	Use:   "update",
-	Short: "Update to the latest version",
-	RunE:  runUpdate,
-}
-
-var authCmd = &cobra.Command{
-	Use:   "auth [token]",
-	Short: "Save GitHub token for future use",
-	Long: `Save a GitHub Personal Access Token for automatic use in future commands.
-The token is stored securely in ~/.config/gha-freeze/token with 0600 permissions.
-
-Alternatively, you can set the GITHUB_TOKEN or GHA_FREEZE_TOKEN environment variable.`,
-	Args: cobra.ExactArgs(1),
-	RunE: runAuth,
-}
-
 func init() {
-	rootCmd.Flags().StringVar(&token, "token", "", "GitHub token (create at: https://github.com/settings/tokens/new?description=gha-freeze&scopes=public_repo)")
+	rootCmd.PersistentFlags().StringVar(&token, "token", "", "GitHub token (create at: https://github.com/settings/tokens/new?description=gha-freeze&scopes=public_repo)")
+	rootCmd.PersistentFlags().Int64Var(&appID, "app-id", 0, "GitHub App ID (or GHA_FREEZE_APP_ID)")
+	rootCmd.PersistentFlags().Int64Var(&appInstallID, "app-installation-id", 0, "GitHub App installation ID (or GHA_FREEZE_APP_INSTALLATION_ID)")
+	rootCmd.PersistentFlags().StringVarP(&repoDir, "repo", "C", "", "Run in this repository (any directory inside it) instead of the current directory")
+	rootCmd.PersistentFlags().StringVar(&appKeyPath, "app-private-key", "", "Path to the GitHub App private key PEM (or GHA_FREEZE_APP_PRIVATE_KEY)")
+	rootCmd.PersistentFlags().StringVar(&hostName, "host", "", "Resolve actions against this GitHub Enterprise Server, Gitea or Forgejo host (or GHA_FREEZE_HOST)")
+	rootCmd.PersistentFlags().StringVar(&hostType, "host-type", "", "Type of --host: auto, github or gitea (default auto)")
+	rootCmd.PersistentFlags().StringVar(&resolverOpt, "resolver", "", "How to resolve tags and branches: api, or git to list refs over the git protocol and fall back to the API (or GHA_FREEZE_RESOLVER)")
+	rootCmd.Flags().StringArrayVar(&workflowDirOpt, "workflow-dir", nil, "Workflow directory to scan (repeatable; default: detect .github/workflows, .gitea/workflows, .forgejo/workflows)")
 	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Preview changes without modifying files")
 	rootCmd.Flags().BoolVar(&noBackup, "no-backup", false, "Skip creating backup files")
+	rootCmd.Flags().StringVar(&backupLoc, "backup-location", "", "Where to store backups: repo (.github/workflows) or state (XDG state dir)")
+	rootCmd.Flags().StringVar(&gitBranch, "git-branch", "", "Create this branch and commit the pinned workflows to it (implies --commit)")
+	rootCmd.Flags().BoolVar(&gitCommit, "commit", false, "Commit the pinned workflows instead of creating file backups")
+	rootCmd.Flags().BoolVar(&force, "force", false, "Commit even if the affected workflow files have uncommitted changes")
+	rootCmd.Flags().StringVar(&diffOutput, "diff", "", "Print a git-apply compatible patch instead of modifying files (--diff=FILE to write it to a file)")
+	rootCmd.Flags().Lookup("diff").NoOptDefVal = "-"
+	rootCmd.Flags().BoolVar(&openPR, "pr", false, "Commit the pinned workflows to a branch on GitHub via the API and open or update a pull request")
+	rootCmd.Flags().StringVar(&prBranch, "pr-branch", "", "Branch for --pr (default \""+github.DefaultPRBranch+"\")")
+	rootCmd.Flags().StringVar(&prBase, "pr-base", "", "Base branch for --pr (default: the repository's default branch)")
+	rootCmd.MarkFlagsMutuallyExclusive("pr", "diff")
+	rootCmd.MarkFlagsMutuallyExclusive("pr", "dry-run")
+	rootCmd.MarkFlagsMutuallyExclusive("pr", "git-branch")
+	rootCmd.MarkFlagsMutuallyExclusive("pr", "commit")
 	rootCmd.Flags().BoolVar(&checkUpdate, "check-update", false, "Check for updates without installing")
-	rootCmd.Flags().BoolVar(&skipUpdateChk, "skip-update-check", false, "Skip automatic update check on startup")
+	rootCmd.Flags().BoolVar(&skipUpdateChk, "skip-update-check", false, "Skip the background update check")
 
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(updateCmd)
 	rootCmd.AddCommand(authCmd)
-}
-
-func runAuth(cmd *cobra.Command, args []string) error {
-	token := args[0]
-	if err := config.SaveToken(token); err != nil {
-		return fmt.Errorf("failed to save token: %w", err)
-	}
-
-	tokenPath, _ := config.GetTokenPath()
-	fmt.Printf("✓ Token saved to %s\n", tokenPath)
-	fmt.Printf("The token will be used automatically for future commands.\n")
-	return nil
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(scanCmd)
 }
 
 func run(cmd *cobra.Command, args []string) error {
@@ -93,99 +100,136 @@ func run(cmd *cobra.Command, args []string) error {
 		return checkForUpdates()
 	}
 
-	if !skipUpdateChk {
-		_ = checkAndNotifyUpdate()
+	if diffOutput == "" && updateCheckEnabled() {
+		defer notifyUpdate(startUpdateCheck())
+	}
+
+	client, err := newResolveClient()
+	if err != nil {
+		return err
+	}
+
+	root, err := repoRoot()
+	if err != nil {
+		return err
+	}
+
+	var pr *tui.PullRequest
+	if openPR {
+		if err := requireGitHub("--pr"); err != nil {
+			return err
+		}
+		if pr, err = pullRequestTarget(client, root); err != nil {
+			return err
+		}
 	}
 
-	if _, err := os.Stat(".git"); os.IsNotExist(err) {
-		return fmt.Errorf("not a git repository. Please run this command from the root of a git repository")
+	dirs, err := workflowDirs(root)
+	if err != nil {
+		return err
 	}
 
-	if _, err := os.Stat(".github/workflows"); os.IsNotExist(err) {
-		return fmt.Errorf(".github/workflows directory not found")
+	if diffOutput != "" {
+		return runDiffMode(client, root, dirs, diffOutput)
 	}
 
-	resolvedToken := config.GetToken(token)
+	loc, err := resolveBackupLocation()
+	if err != nil {
+		return err
+	}
 
-	m := tui.NewModel(resolvedToken, dryRun, noBackup, version)
+	m := tui.NewModel(client, tui.Options{
+		DryRun:         dryRun,
+		NoBackup:       noBackup,
+		BackupLocation: loc,
+		GitBranch:      gitBranch,
+		Commit:         gitCommit,
+		Force:          force,
+		Version:        version,
+		WorkflowDirs:   dirs,
+		Root:           root,
+		PullRequest:    pr,
+	})
 	p := tea.NewProgram(m)
 
-	if _, err := p.Run(); err != nil {
+	finalModel, err := p.Run()
+	if err != nil {
 		return fmt.Errorf("error running program: %w", err)
 	}
 
+	if fm, ok := finalModel.(tui.Model); ok && len(fm.Failures()) > 0 {
+		return fmt.Errorf("%d actions could not be resolved and were left unpinned", len(fm.Failures()))
+	}
+
 	return nil
 }
 
-func runUpdate(cmd *cobra.Command, args []string) error {
-	fmt.Printf("Checking for updates...\n")
+// repoRoot returns the root of the repository given by --repo, or the one
+// containing the current directory. Workflow and backup paths are relative
+// to it.
+func repoRoot() (string, error) {
+	dir := repoDir
+	if dir == "" {
+		dir = "."
+	}
 
-	resolvedToken := config.GetToken(token)
-	info, err := updater.CheckForUpdateWithToken(version, resolvedToken)
+	root, err := git.FindRoot(dir)
 	if err != nil {
-		if github.IsRateLimitError(err) {
-			fmt.Printf("\nGitHub API rate limit reached.\n\n")
-			fmt.Printf("Create a token to get higher rate limits:\n")
-			fmt.Printf("%s\n\n", getTokenCreationURL())
-			fmt.Printf("Then save it: gha-freeze auth YOUR_TOKEN\n")
-			return nil
+		if errors.Is(err, git.ErrNotRepository) {
+			return "", fmt.Errorf("not a git repository: run gha-freeze inside a repository or pass --repo")
 		}
-		return fmt.Errorf("failed to check for updates: %w", err)
+		return "", err
 	}
+	return root, nil
+}
 
-	if !info.Available {
-		fmt.Printf("You are already on the latest version (%s)\n", info.CurrentVersion)
-		return nil
+func newGitHubClient() (*github.Client, error) {
+	f, err := resolveForge()
+	if err != nil {
+		return nil, err
 	}
-
-	fmt.Printf("New version available: %s -> %s\n", info.CurrentVersion, info.LatestVersion)
-	if info.ReleaseNotes != "" {
-		fmt.Printf("\nRelease Notes:\n%s\n\n", info.ReleaseNotes)
+	if f.gitea {
+		return nil, fmt.Errorf("this command needs GitHub, but %s is a Gitea or Forgejo host", f.host)
 	}
+	return newGitHubClientFor(f)
+}
 
-	if info.DownloadURL == "" {
-		fmt.Printf("Automatic update not available for your platform.\n")
-		fmt.Printf("Download manually from: https://github.com/thinesjs/gha-freeze/releases/latest\n")
-		return nil
+func newGitHubClientFor(f forge) (*github.Client, error) {
+	creds, err := config.GetAppCredentials(appID, appInstallID, appKeyPath)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Downloading and installing update...\n")
-	if err := updater.DownloadAndInstall(info.DownloadURL); err != nil {
-		return fmt.Errorf("failed to install update: %w", err)
+	var opts []github.Option
+	if baseURL := f.apiURL(); baseURL != "" {
+		opts = append(opts, github.WithBaseURL(baseURL))
 	}
 
-	fmt.Printf("✓ Successfully updated to version %s\n", info.LatestVersion)
-	fmt.Printf("Please restart gha-freeze to use the new version\n")
+	if creds != nil {
+		transport, err := github.NewAppTransport(creds.AppID, creds.InstallationID, creds.PrivateKey, f.apiURL())
+		if err != nil {
+			return nil, err
+		}
+		return github.NewClient("", append(opts, github.WithAppTransport(transport))...), nil
+	}
 
-	return nil
+	return github.NewClient(f.githubToken(), opts...), nil
 }
 
-func checkForUpdates() error {
-	resolvedToken := config.GetToken(token)
-	info, err := updater.CheckForUpdateWithToken(version, resolvedToken)
-	if err != nil {
-		if github.IsRateLimitError(err) {
-			fmt.Printf("GitHub API rate limit reached.\n\n")
-			fmt.Printf("Create a token to get higher rate limits:\n")
-			fmt.Printf("%s\n\n", getTokenCreationURL())
-			fmt.Printf("Then save it: gha-freeze auth YOUR_TOKEN\n")
-			return nil
-		}
-		return fmt.Errorf("failed to check for updates: %w", err)
+func resolveBackupLocation() (backup.Location, error) {
+	if backupLoc != "" {
+		return backup.ParseLocation(backupLoc)
 	}
 
-	if info.Available {
-		fmt.Printf("New version available: %s -> %s\n", info.CurrentVersion, info.LatestVersion)
-		if info.DownloadURL != "" {
-			fmt.Printf("Run 'gha-freeze update' to install\n")
-		} else {
-			fmt.Printf("Download from: https://github.com/thinesjs/gha-freeze/releases/latest\n")
-		}
-	} else {
-		fmt.Printf("You are on the latest version (%s)\n", info.CurrentVersion)
+	if env := os.Getenv("GHA_FREEZE_BACKUP_LOCATION"); env != "" {
+		return backup.ParseLocation(env)
 	}
 
-	return nil
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return "", fmt.Errorf("failed to load settings: %w", err)
+	}
+	return backup.ParseLocation(settings.BackupLocation)
 }
 
 func getTokenCreationURL() string {
@@ -195,30 +239,6 @@ func getTokenCreationURL() string {
 		url.QueryEscape(description))
 }
 
-func checkAndNotifyUpdate() error {
-	resolvedToken := config.GetToken(token)
-	info, err := updater.CheckForUpdateWithToken(version, resolvedToken)
-	if err != nil {
-		return err
-	}
-
-	if info.Available {
-		fmt.Printf("\n")
-		fmt.Printf("╭─────────────────────────────────────────────────╮\n")
-		fmt.Printf("│  New version available: %s -> %s", info.CurrentVersion, info.LatestVersion)
-		padding := 49 - 24 - len(info.CurrentVersion) - len(info.LatestVersion)
-		for i := 0; i < padding; i++ {
-			fmt.Printf(" ")
-		}
-		fmt.Printf("│\n")
-		fmt.Printf("│  Run 'gha-freeze update' to install             │\n")
-		fmt.Printf("╰─────────────────────────────────────────────────╯\n")
-		fmt.Printf("\n")
-	}
-
-	return nil
-}
-
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/thinesjs/gha-freeze/internal/diff"
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+func runDiffMode(client *github.Client, root string, dirs []string, output string) error {
+	files, err := workflow.FindWorkflowFilesIn(root, dirs)
+	if err != nil {
+		return err
+	}
+
+	var actions []workflow.ActionReference
+	for _, file := range files {
+		found, err := workflow.ParseWorkflowFile(root, file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		for _, action := range found {
+			if !action.IsPinned {
+				actions = append(actions, action)
+			}
+		}
+	}
+
+	var replacements []workflow.Replacement
+	var failed int
+	for _, action := range actions {
+		resolved := client.ResolveAction(action.Owner, action.Repo, action.Ref)
+		if resolved.Error != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s/%s@%s (%s:%d): %s\n",
+				action.Owner, action.Repo, action.Ref, action.FilePath, action.Line,
+				github.ClassifyResolveError(resolved.Error))
+			continue
+		}
+		replacements = append(replacements, workflow.Replacement{
+			Action:  action,
+			SHA:     resolved.SHA,
+			Version: resolved.Version,
+		})
+	}
+
+	changes, err := workflow.PlanChanges(root, replacements)
+	if err != nil {
+		return err
+	}
+
+	var patch strings.Builder
+	for _, c := range changes {
+		patch.WriteString(diff.GitPatch(c.Path, c.Old, c.New))
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			return fmt.Errorf("failed to create patch file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	if _, err := io.WriteString(w, patch.String()); err != nil {
+		return fmt.Errorf("failed to write patch: %w", err)
+	}
+
+	if output != "-" {
+		fmt.Fprintf(os.Stderr, "Wrote patch for %d actions in %d files to %s\n", len(replacements), len(changes), output)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d actions could not be resolved and were left out of the patch", failed)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/git"
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/remote"
+	"github.com/thinesjs/gha-freeze/internal/tui"
+)
+
+var (
+	openPR   bool
+	prBranch string
+	prBase   string
+)
+
+// pullRequestTarget sets up --pr for the checkout at root: the workflows are
+// pinned locally as usual, then proposed as a pull request on the origin
+// repository instead of being written to the working tree.
+func pullRequestTarget(client *github.Client, root string) (*tui.PullRequest, error) {
+	repo, err := git.Open(root)
+	if err != nil {
+		return nil, err
+	}
+
+	remoteURL, err := repo.RemoteURL("origin")
+	if err != nil {
+		return nil, err
+	}
+	_, owner, name, err := git.ParseRemote(remoteURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tui.PullRequest{
+		Pinner: newPinner(client),
+		Owner:  owner,
+		Repo:   name,
+		Base:   prBase,
+	}, nil
+}
+
+func newPinner(client *github.Client) *remote.Pinner {
+	pinner := remote.NewPinner(client)
+	if prBranch != "" {
+		pinner.Branch = prBranch
+	}
+	pinner.OnWait = func(wait time.Duration) {
+		fmt.Fprintf(os.Stderr, "Rate limit reached, waiting %s...\n", wait.Round(time.Second))
+	}
+	return pinner
+}
+
+func printPinResult(result *remote.PinResult) {
+	for _, f := range result.Failures {
+		fmt.Fprintf(os.Stderr, "✗ %s: %s/%s@%s (%s:%d): %s\n", result.Repo,
+			f.Action.Owner, f.Action.Repo, f.Action.Ref, f.Action.FilePath, f.Action.Line, f.Reason)
+	}
+
+	switch {
+	case result.PR == nil && len(result.Failures) == 0:
+		fmt.Printf("%s: all actions on %s are already pinned\n", result.Repo, result.Base)
+	case result.PR == nil:
+		fmt.Printf("%s: nothing could be pinned\n", result.Repo)
+	case result.PR.Updated:
+		fmt.Printf("✓ %s: updated pull request #%d (%d actions): %s\n", result.Repo, result.PR.Number, len(result.Replacements), result.PR.URL)
+	default:
+		fmt.Printf("✓ %s: opened pull request #%d (%d actions): %s\n", result.Repo, result.PR.Number, len(result.Replacements), result.PR.URL)
+	}
+}
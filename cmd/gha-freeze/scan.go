@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/remote"
+)
+
+var (
+	scanOrg         string
+	scanInclude     []string
+	scanExclude     []string
+	scanArchived    bool
+	scanForks       bool
+	scanFormat      string
+	scanOutput      string
+	scanConcurrency int
+	scanOpenPRs     bool
+)
+
+var scanCmd = &cobra.Command{
+	Use:   "scan --org NAME",
+	Short: "Report unpinned actions across an organization without cloning",
+	Long: `List the repositories of an organization through the GitHub API, fetch
+.github/workflows from each default branch and report which actions are used
+and which are unpinned, per repository and per action.
+
+--include and --exclude take glob patterns matched against the repository name
+(e.g. "svc-*") and can be repeated. Archived repositories and forks are skipped
+unless asked for. When the API rate limit is hit the scan waits for it to reset.
+
+With --open-prs, every repository that uses unpinned actions also gets a pull
+request (created or updated) that pins them, committed through the API.`,
+	Args: cobra.NoArgs,
+	RunE: runScan,
+}
+
+func init() {
+	scanCmd.Flags().StringVar(&scanOrg, "org", "", "Organization to scan")
+	scanCmd.Flags().StringArrayVar(&scanInclude, "include", nil, "Only scan repositories matching this pattern (repeatable)")
+	scanCmd.Flags().StringArrayVar(&scanExclude, "exclude", nil, "Skip repositories matching this pattern (repeatable)")
+	scanCmd.Flags().BoolVar(&scanArchived, "include-archived", false, "Also scan archived repositories")
+	scanCmd.Flags().BoolVar(&scanForks, "include-forks", false, "Also scan forks")
+	scanCmd.Flags().StringVar(&scanFormat, "format", "text", "Report format: text, json or csv")
+	scanCmd.Flags().StringVarP(&scanOutput, "output", "o", "", "Write the report to a file instead of stdout")
+	scanCmd.Flags().IntVar(&scanConcurrency, "concurrency", remote.DefaultConcurrency, "Repositories scanned in parallel")
+	scanCmd.Flags().BoolVar(&scanOpenPRs, "open-prs", false, "Open or update a pull request pinning the actions in each affected repository")
+	scanCmd.Flags().StringVar(&prBranch, "pr-branch", "", "Branch for --open-prs (default \""+github.DefaultPRBranch+"\")")
+	_ = scanCmd.MarkFlagRequired("org")
+}
+
+func runScan(cmd *cobra.Command, args []string) error {
+	format, err := remote.ParseFormat(scanFormat)
+	if err != nil {
+		return err
+	}
+
+	client, err := newGitHubClient()
+	if err != nil {
+		return err
+	}
+
+	progress := term.IsTerminal(os.Stderr.Fd())
+	opts := remote.Options{
+		Include:         scanInclude,
+		Exclude:         scanExclude,
+		IncludeArchived: scanArchived,
+		IncludeForks:    scanForks,
+		Concurrency:     scanConcurrency,
+		OnWait: func(wait time.Duration) {
+			fmt.Fprintf(os.Stderr, "Rate limit reached, waiting %s...\n", wait.Round(time.Second))
+		},
+		OnRepo: func(done, total int, repo remote.RepoReport) {
+			if progress {
+				fmt.Fprintf(os.Stderr, "\r\033[KScanned %d/%d: %s", done, total, repo.Repo)
+			}
+		},
+	}
+
+	report, err := remote.ScanOrg(cmd.Context(), client, scanOrg, opts)
+	if progress {
+		fmt.Fprintf(os.Stderr, "\r\033[K")
+	}
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if scanOutput != "" {
+		f, err := os.Create(scanOutput)
+		if err != nil {
+			return fmt.Errorf("failed to create report file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	if err := report.Write(w, format); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if scanOutput != "" {
+		fmt.Fprintf(os.Stderr, "Wrote %s report for %d repositories to %s\n", format, len(report.Repos), scanOutput)
+	}
+
+	if scanOpenPRs {
+		return openScanPRs(cmd.Context(), client, report)
+	}
+	return nil
+}
+
+func openScanPRs(ctx context.Context, client *github.Client, report *remote.Report) error {
+	pinner := newPinner(client)
+
+	var failed int
+	for _, repo := range report.Repos {
+		if len(repo.Errors) > 0 || repo.Unpinned == 0 {
+			continue
+		}
+
+		owner, name, _ := strings.Cut(repo.Repo, "/")
+		result, err := pinner.Pin(ctx, owner, name, repo.Branch)
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", repo.Repo, err)
+			continue
+		}
+		printPinResult(result)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to open pull requests for %d repositories", failed)
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+
+	"github.com/thinesjs/gha-freeze/internal/config"
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/updater"
+)
+
+var (
+	updateVersion  string
+	updateChannel  string
+	updateRollback bool
+)
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update to the latest version",
+	Long: `Update gha-freeze to the newest release on the configured channel.
+
+The channel is "stable" by default; "beta" also considers prereleases. Set it
+with --channel, GHA_FREEZE_UPDATE_CHANNEL, or update_channel in
+~/.config/gha-freeze/config.yml. Use --version to install a specific release,
+including an older one.
+
+Releases come from github.com unless update_source in config.yml (or
+GHA_FREEZE_UPDATE_REPO, GHA_FREEZE_UPDATE_API_URL, GHA_FREEZE_UPDATE_URL)
+points at a GitHub Enterprise repository or a release mirror.
+
+Each update keeps the replaced binary in the state directory
+(~/.local/state/gha-freeze/previous); --rollback restores it. A new binary that
+fails to run "gha-freeze version" after installation is rolled back
+automatically.`,
+	RunE: runUpdate,
+}
+
+func init() {
+	updateCmd.Flags().StringVar(&updateVersion, "version", "", "Install a specific version (e.g. v0.2.0), including downgrades")
+	updateCmd.Flags().StringVar(&updateChannel, "channel", "", "Release channel: stable or beta")
+	updateCmd.Flags().BoolVar(&updateRollback, "rollback", false, "Restore the version that was installed before the last update")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "version")
+	updateCmd.MarkFlagsMutuallyExclusive("rollback", "channel")
+}
+
+func updateCheckOptions() (updater.CheckOptions, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return updater.CheckOptions{}, fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	channel := updateChannel
+	if channel == "" {
+		channel = os.Getenv("GHA_FREEZE_UPDATE_CHANNEL")
+	}
+	if channel == "" {
+		channel = settings.UpdateChannel
+	}
+
+	ch, err := updater.ParseChannel(channel)
+	if err != nil {
+		return updater.CheckOptions{}, err
+	}
+
+	source := updateSource(settings.UpdateSource)
+	if err := source.Validate(); err != nil {
+		return updater.CheckOptions{}, err
+	}
+
+	// Only a token issued for the source's own host is sent.
+	sourceToken, _ := config.ResolveTokenForHost(token, source.Host())
+
+	return updater.CheckOptions{
+		Token:   sourceToken,
+		Channel: ch,
+		Source:  source,
+	}, nil
+}
+
+// updateSource applies the GHA_FREEZE_UPDATE_* environment variables on top
+// of update_source in config.yml. Setting GHA_FREEZE_UPDATE_URL selects a
+// mirror and ignores the configured repository.
+func updateSource(s config.UpdateSource) updater.Source {
+	if u := os.Getenv("GHA_FREEZE_UPDATE_URL"); u != "" {
+		return updater.Source{URL: u}
+	}
+
+	source := updater.Source{Repo: s.Repo, APIURL: s.APIURL, URL: s.URL}
+	if r := os.Getenv("GHA_FREEZE_UPDATE_REPO"); r != "" {
+		source.Repo = r
+		source.URL = ""
+	}
+	if a := os.Getenv("GHA_FREEZE_UPDATE_API_URL"); a != "" {
+		source.APIURL = a
+		source.URL = ""
+	}
+	return source
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	if updateRollback {
+		return runRollback()
+	}
+
+	fmt.Printf("Checking for updates...\n")
+
+	opts, err := updateCheckOptions()
+	if err != nil {
+		return err
+	}
+	opts.Version = updateVersion
+
+	info, err := updater.Check(version, opts)
+	if err != nil {
+		if github.IsRateLimitError(err) {
+			fmt.Printf("\nGitHub API rate limit reached.\n\n")
+			fmt.Printf("Create a token to get higher rate limits:\n")
+			fmt.Printf("%s\n\n", getTokenCreationURL())
+			fmt.Printf("Then save it: gha-freeze auth login\n")
+			return nil
+		}
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if !info.Available {
+		if updateVersion != "" {
+			fmt.Printf("Version %s is already installed\n", info.CurrentVersion)
+		} else {
+			fmt.Printf("You are already on the latest version (%s)\n", info.CurrentVersion)
+		}
+		return nil
+	}
+
+	if info.Downgrade {
+		fmt.Printf("Downgrading: %s -> %s\n", info.CurrentVersion, info.LatestVersion)
+	} else {
+		fmt.Printf("New version available: %s -> %s\n", info.CurrentVersion, info.LatestVersion)
+	}
+	if info.ReleaseNotes != "" {
+		fmt.Printf("\nRelease Notes:\n%s\n\n", info.ReleaseNotes)
+	}
+
+	inst, err := updater.DetectInstallation()
+	if err != nil {
+		return fmt.Errorf("failed to inspect installation: %w", err)
+	}
+	if inst.Managed() {
+		fmt.Printf("gha-freeze was installed with %s (%s).\n", inst.Method, inst.Path)
+		fmt.Printf("Upgrade with: %s\n", inst.UpgradeCommand())
+		return nil
+	}
+	if !inst.Writable {
+		return fmt.Errorf("cannot update %s: its directory is not writable; re-run with elevated permissions or reinstall to a user-writable directory", inst.Path)
+	}
+
+	if info.DownloadURL == "" {
+		fmt.Printf("Automatic update not available for your platform.\n")
+		fmt.Printf("Download manually from: %s\n", info.ReleasesURL)
+		return nil
+	}
+
+	fmt.Printf("Downloading and installing update...\n")
+	if err := updater.DownloadAndInstall(info); err != nil {
+		return fmt.Errorf("failed to install update: %w", err)
+	}
+
+	fmt.Printf("✓ Successfully installed version %s\n", info.LatestVersion)
+	if prev, err := updater.LoadPreviousBinary(); err == nil && prev != nil {
+		fmt.Printf("Previous version %s kept; run 'gha-freeze update --rollback' to restore it\n", prev.Version)
+	}
+	fmt.Printf("Please restart gha-freeze to use the new version\n")
+
+	return nil
+}
+
+func runRollback() error {
+	prev, err := updater.LoadPreviousBinary()
+	if err != nil {
+		return fmt.Errorf("failed to read previous version: %w", err)
+	}
+	if prev == nil {
+		return fmt.Errorf("no previous version to roll back to; one is kept after each 'gha-freeze update'")
+	}
+
+	fmt.Printf("Rolling back: %s -> %s\n", version, prev.Version)
+	restored, err := updater.Rollback(version)
+	if err != nil {
+		return fmt.Errorf("failed to roll back: %w", err)
+	}
+
+	fmt.Printf("✓ Restored version %s\n", restored)
+	return nil
+}
+
+func checkForUpdates() error {
+	opts, err := updateCheckOptions()
+	if err != nil {
+		return err
+	}
+
+	info, err := updater.Check(version, opts)
+	if err != nil {
+		if github.IsRateLimitError(err) {
+			fmt.Printf("GitHub API rate limit reached.\n\n")
+			fmt.Printf("Create a token to get higher rate limits:\n")
+			fmt.Printf("%s\n\n", getTokenCreationURL())
+			fmt.Printf("Then save it: gha-freeze auth login\n")
+			return nil
+		}
+		return fmt.Errorf("failed to check for updates: %w", err)
+	}
+
+	if info.Available {
+		fmt.Printf("New version available: %s -> %s\n", info.CurrentVersion, info.LatestVersion)
+		if info.DownloadURL != "" {
+			fmt.Printf("Run '%s' to install\n", upgradeCommand())
+		} else {
+			fmt.Printf("Download from: %s\n", info.ReleasesURL)
+		}
+	} else {
+		fmt.Printf("You are on the latest version (%s)\n", info.CurrentVersion)
+	}
+
+	return nil
+}
+
+// updateCheckEnabled reports whether the startup check should run. It is
+// skipped in CI, when stdout is not a terminal, or when
+// GHA_FREEZE_NO_UPDATE_CHECK is set.
+func updateCheckEnabled() bool {
+	if skipUpdateChk || os.Getenv("GHA_FREEZE_NO_UPDATE_CHECK") != "" || os.Getenv("CI") != "" {
+		return false
+	}
+	return term.IsTerminal(os.Stdout.Fd())
+}
+
+func updateCheckInterval() time.Duration {
+	settings, err := config.LoadSettings()
+	if err != nil || settings.UpdateCheckInterval == "" {
+		return updater.DefaultCheckInterval
+	}
+
+	interval, err := parseAge(settings.UpdateCheckInterval)
+	if err != nil {
+		return updater.DefaultCheckInterval
+	}
+	return interval
+}
+
+// startUpdateCheck runs the throttled update check in the background so it
+// never delays startup. The channel receives nil when there is nothing to
+// report.
+func startUpdateCheck() <-chan *updater.UpdateInfo {
+	result := make(chan *updater.UpdateInfo, 1)
+
+	go func() {
+		defer close(result)
+
+		opts, err := updateCheckOptions()
+		if err != nil {
+			return
+		}
+		statePath, err := config.GetUpdateCheckPath()
+		if err != nil {
+			return
+		}
+
+		info, err := updater.CheckThrottled(version, opts, statePath, updateCheckInterval())
+		if err == nil && info.Available {
+			result <- info
+		}
+	}()
+
+	return result
+}
+
+// updateCheckGrace is how long notifyUpdate waits for a background check
+// that is still running when the command is done.
+const updateCheckGrace = 2 * time.Second
+
+// notifyUpdate prints the notice once the background check has finished.
+// A check still running when the command is done gets updateCheckGrace to
+// finish and save its state; otherwise every short command would cut the
+// check off and the next run would hit the network again.
+func notifyUpdate(result <-chan *updater.UpdateInfo) {
+	var info *updater.UpdateInfo
+	select {
+	case info = <-result:
+	case <-time.After(updateCheckGrace):
+	}
+	if info == nil {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Fprintf(os.Stderr, "╭─────────────────────────────────────────────────╮\n")
+	fmt.Fprintf(os.Stderr, "│  New version available: %s -> %s", info.CurrentVersion, info.LatestVersion)
+	padding := 49 - 24 - len(info.CurrentVersion) - len(info.LatestVersion)
+	for i := 0; i < padding; i++ {
+		fmt.Fprintf(os.Stderr, " ")
+	}
+	fmt.Fprintf(os.Stderr, "│\n")
+	hint := fmt.Sprintf("Run '%s' to install", upgradeCommand())
+	fmt.Fprintf(os.Stderr, "│  %-47s│\n", hint)
+	fmt.Fprintf(os.Stderr, "╰─────────────────────────────────────────────────╯\n")
+	fmt.Fprintf(os.Stderr, "\n")
+}
+
+// upgradeCommand is the command that upgrades this installation, which is
+// the package manager's when gha-freeze was not installed from a release.
+func upgradeCommand() string {
+	inst, err := updater.DetectInstallation()
+	if err != nil {
+		return "gha-freeze update"
+	}
+	return inst.UpgradeCommand()
+}
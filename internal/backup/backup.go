@@ -1,26 +1,114 @@
 package backup
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/config"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+const manifestFile = "manifest.json"
+
+type Location string
+
+const (
+	LocationRepo  Location = "repo"
+	LocationState Location = "state"
 )
 
-func CreateBackup(files []string) (string, error) {
-	timestamp := time.Now().Format("20060102-150405")
-	backupDir := filepath.Join(".github", "workflows", fmt.Sprintf(".backup-%s", timestamp))
+func ParseLocation(s string) (Location, error) {
+	switch Location(s) {
+	case "", LocationRepo:
+		return LocationRepo, nil
+	case LocationState:
+		return LocationState, nil
+	}
+	return "", fmt.Errorf("invalid backup location %q: expected \"repo\" or \"state\"", s)
+}
+
+type Manifest struct {
+	Version   string         `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Repo      string         `json:"repo,omitempty"`
+	Files     []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Path   string      `json:"path"`
+	Mode   os.FileMode `json:"mode"`
+	SHA256 string      `json:"sha256"`
+}
+
+// CreateBackup copies files, given relative to the repository at root, into
+// a new backup and returns its directory.
+func CreateBackup(root string, files []string, version string, loc Location) (string, error) {
+	now := time.Now()
+	timestamp := now.Format("20060102-150405")
+
+	var backupDir string
+	manifest := Manifest{Version: version, CreatedAt: now.UTC()}
+
+	switch loc {
+	case LocationState:
+		dir, err := StateBackupDir(root)
+		if err != nil {
+			return "", fmt.Errorf("failed to locate state directory: %w", err)
+		}
+		backupDir = filepath.Join(dir, fmt.Sprintf("backup-%s", timestamp))
+		manifest.Repo, _ = filepath.Abs(root)
+	default:
+		backupDir = filepath.Join(root, repoBackupParent(root, files), fmt.Sprintf(".backup-%s", timestamp))
+	}
 
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
 	for _, file := range files {
-		if err := copyFile(file, filepath.Join(backupDir, filepath.Base(file))); err != nil {
+		relPath, err := repoRelativePath(root, file)
+		if err != nil {
+			return "", fmt.Errorf("failed to backup %s: %w", file, err)
+		}
+		src := filepath.Join(root, relPath)
+
+		stat, err := os.Stat(src)
+		if err != nil {
+			return "", fmt.Errorf("failed to backup %s: %w", file, err)
+		}
+
+		dst := filepath.Join(backupDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 			return "", fmt.Errorf("failed to backup %s: %w", file, err)
 		}
+
+		if err := copyFile(src, dst); err != nil {
+			return "", fmt.Errorf("failed to backup %s: %w", file, err)
+		}
+
+		sum, err := fileSHA256(dst)
+		if err != nil {
+			return "", fmt.Errorf("failed to checksum %s: %w", file, err)
+		}
+
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:   filepath.ToSlash(relPath),
+			Mode:   stat.Mode().Perm(),
+			SHA256: sum,
+		})
+	}
+
+	if err := writeManifest(backupDir, manifest); err != nil {
+		return "", err
 	}
 
 	return backupDir, nil
@@ -30,26 +118,181 @@ type BackupInfo struct {
 	Path      string
 	Timestamp string
 	FileCount int
+	Location  Location
+	Manifest  *Manifest
+}
+
+// StateBackupDir returns the per-repository directory used for backups kept
+// outside the working tree, keyed by the absolute path of root.
+func StateBackupDir(root string) (string, error) {
+	state, err := config.StateDir()
+	if err != nil {
+		return "", err
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+	key := fmt.Sprintf("%s-%s", filepath.Base(abs), hex.EncodeToString(sum[:])[:12])
+	return filepath.Join(state, "backups", key), nil
+}
+
+type BackupFile struct {
+	Path   string
+	Source string
+}
+
+func (b BackupInfo) CreatedAt() time.Time {
+	if b.Manifest != nil && !b.Manifest.CreatedAt.IsZero() {
+		return b.Manifest.CreatedAt
+	}
+	t, _ := time.ParseInLocation("20060102-150405", b.Timestamp, time.Local)
+	return t
 }
 
-func ListBackups() ([]BackupInfo, error) {
-	workflowDir := ".github/workflows"
+func (b BackupInfo) Files() ([]BackupFile, error) {
+	var files []BackupFile
+
+	if b.Manifest != nil {
+		for _, f := range b.Manifest.Files {
+			files = append(files, BackupFile{
+				Path:   filepath.FromSlash(f.Path),
+				Source: filepath.Join(b.Path, filepath.FromSlash(f.Path)),
+			})
+		}
+		return files, nil
+	}
+
+	entries, err := os.ReadDir(b.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, BackupFile{
+				Path:   filepath.Join(".github", "workflows", entry.Name()),
+				Source: filepath.Join(b.Path, entry.Name()),
+			})
+		}
+	}
+	return files, nil
+}
+
+// SelectFiles returns the files of the backup named by only, which are
+// resolved against root like RestoreFiles does, or every file if only is
+// empty. A file that is not part of the backup is an error.
+func (b BackupInfo) SelectFiles(root string, only []string) ([]BackupFile, error) {
+	files, err := b.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted, err := selectFiles(root, b, only)
+	if err != nil || wanted == nil {
+		return files, err
+	}
+
+	selected := files[:0]
+	for _, f := range files {
+		if wanted[f.Path] {
+			selected = append(selected, f)
+		}
+	}
+	return selected, nil
+}
+
+func FindBackup(root, id string) (BackupInfo, error) {
+	backups, err := ListBackups(root)
+	if err != nil {
+		return BackupInfo{}, err
+	}
+
+	if len(backups) == 0 {
+		return BackupInfo{}, fmt.Errorf("no backups found")
+	}
+
+	if id == "latest" {
+		return backups[len(backups)-1], nil
+	}
+
+	// A path may be given relative to the current directory or, as listed,
+	// to the repository.
+	paths := []string{filepath.Clean(id), filepath.Join(root, id)}
+	if abs, err := filepath.Abs(id); err == nil {
+		paths = append(paths, abs)
+	}
+	for _, b := range backups {
+		if b.Timestamp == id || filepath.Base(b.Path) == id || slices.Contains(paths, filepath.Clean(b.Path)) {
+			return b, nil
+		}
+	}
+
+	return BackupInfo{}, fmt.Errorf("backup %q not found", id)
+}
+
+// ListBackups returns the backups of the repository at root, oldest first.
+func ListBackups(root string) ([]BackupInfo, error) {
 	var backups []BackupInfo
+	for _, workflowDir := range workflow.DefaultDirs {
+		found, err := scanBackups(filepath.Join(root, workflowDir), ".backup-", LocationRepo)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read workflows directory: %w", err)
+		}
+		backups = append(backups, found...)
+	}
+
+	if stateDir, serr := StateBackupDir(root); serr == nil {
+		stateBackups, serr := scanBackups(stateDir, "backup-", LocationState)
+		if serr != nil && !os.IsNotExist(serr) {
+			return nil, fmt.Errorf("failed to read backup state directory: %w", serr)
+		}
+		backups = append(backups, stateBackups...)
+	}
+
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Timestamp < backups[j].Timestamp
+	})
+
+	return backups, nil
+}
+
+// repoBackupParent picks the workflow directory that holds an in-repo
+// backup: the one containing the first backed-up file, so Gitea and Forgejo
+// repositories do not grow a .github directory.
+func repoBackupParent(root string, files []string) string {
+	if len(files) > 0 {
+		if rel, err := repoRelativePath(root, files[0]); err == nil {
+			dir := filepath.ToSlash(filepath.Dir(rel))
+			for _, d := range workflow.DefaultDirs {
+				if dir == d || strings.HasPrefix(dir, d+"/") {
+					return filepath.FromSlash(d)
+				}
+			}
+		}
+	}
+	return filepath.Join(".github", "workflows")
+}
 
-	entries, err := os.ReadDir(workflowDir)
+func scanBackups(dir, prefix string, loc Location) ([]BackupInfo, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read workflows directory: %w", err)
+		return nil, err
 	}
 
+	var backups []BackupInfo
 	for _, entry := range entries {
-		if entry.IsDir() && strings.HasPrefix(entry.Name(), ".backup-") {
-			backupPath := filepath.Join(workflowDir, entry.Name())
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+			backupPath := filepath.Join(dir, entry.Name())
 			if info, err := validateBackup(backupPath); err == nil {
+				info.Location = loc
 				backups = append(backups, info)
 			}
 		}
 	}
-
 	return backups, nil
 }
 
@@ -61,6 +304,20 @@ func validateBackup(backupPath string) (BackupInfo, error) {
 		info.Timestamp = strings.Join(parts[1:], "-")
 	}
 
+	manifest, err := readManifest(backupPath)
+	if err != nil {
+		return info, err
+	}
+
+	if manifest != nil {
+		if len(manifest.Files) == 0 {
+			return info, fmt.Errorf("no workflow files found in backup")
+		}
+		info.Manifest = manifest
+		info.FileCount = len(manifest.Files)
+		return info, nil
+	}
+
 	entries, err := os.ReadDir(backupPath)
 	if err != nil {
 		return info, fmt.Errorf("failed to read backup directory: %w", err)
@@ -84,15 +341,107 @@ func validateBackup(backupPath string) (BackupInfo, error) {
 	return info, nil
 }
 
-func RestoreBackup(backupPath string) error {
+func RestoreBackup(root, backupPath string) error {
+	return RestoreFiles(root, backupPath, nil)
+}
+
+// RestoreFiles copies the files in only, or all files if only is empty, from
+// the backup into the repository at root.
+func RestoreFiles(root, backupPath string, only []string) error {
 	if backupPath == "" {
 		return fmt.Errorf("no backup path provided")
 	}
 
-	if _, err := validateBackup(backupPath); err != nil {
+	info, err := validateBackup(backupPath)
+	if err != nil {
 		return fmt.Errorf("invalid backup: %w", err)
 	}
 
+	wanted, err := selectFiles(root, info, only)
+	if err != nil {
+		return err
+	}
+
+	if info.Manifest == nil {
+		return restoreLegacyBackup(root, backupPath, wanted)
+	}
+
+	if err := VerifyBackup(info); err != nil {
+		return err
+	}
+
+	for _, f := range info.Manifest.Files {
+		if wanted != nil && !wanted[filepath.FromSlash(f.Path)] {
+			continue
+		}
+		src := filepath.Join(backupPath, filepath.FromSlash(f.Path))
+		dst := filepath.Join(root, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+		if err := copyFile(src, dst); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+		if err := os.Chmod(dst, f.Mode); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func VerifyBackup(info BackupInfo) error {
+	if info.Manifest == nil {
+		return nil
+	}
+
+	for _, f := range info.Manifest.Files {
+		if _, err := safeRelativePath(f.Path); err != nil {
+			return fmt.Errorf("invalid path %q in backup manifest: %w", f.Path, err)
+		}
+
+		sum, err := fileSHA256(filepath.Join(info.Path, filepath.FromSlash(f.Path)))
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", f.Path, err)
+		}
+		if sum != f.SHA256 {
+			return fmt.Errorf("checksum mismatch for %s: backup may be corrupted", f.Path)
+		}
+	}
+
+	return nil
+}
+
+func selectFiles(root string, info BackupInfo, only []string) (map[string]bool, error) {
+	if len(only) == 0 {
+		return nil, nil
+	}
+
+	files, err := info.Files()
+	if err != nil {
+		return nil, err
+	}
+
+	available := make(map[string]bool, len(files))
+	for _, f := range files {
+		available[f.Path] = true
+	}
+
+	wanted := make(map[string]bool, len(only))
+	for _, file := range only {
+		rel, err := repoRelativePath(root, file)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file %s: %w", file, err)
+		}
+		if !available[rel] {
+			return nil, fmt.Errorf("%s is not part of backup %s", file, info.Timestamp)
+		}
+		wanted[rel] = true
+	}
+	return wanted, nil
+}
+
+func restoreLegacyBackup(root, backupPath string, wanted map[string]bool) error {
 	entries, err := os.ReadDir(backupPath)
 	if err != nil {
 		return fmt.Errorf("failed to read backup directory: %w", err)
@@ -104,7 +453,10 @@ func RestoreBackup(backupPath string) error {
 			if ext == ".yml" || ext == ".yaml" {
 				src := filepath.Join(backupPath, entry.Name())
 				dst := filepath.Join(".github/workflows", entry.Name())
-				if err := copyFile(src, dst); err != nil {
+				if wanted != nil && !wanted[dst] {
+					continue
+				}
+				if err := copyFile(src, filepath.Join(root, dst)); err != nil {
 					return fmt.Errorf("failed to restore %s: %w", entry.Name(), err)
 				}
 			}
@@ -126,6 +478,73 @@ func DeleteBackup(backupPath string) error {
 	return nil
 }
 
+func readManifest(backupPath string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(backupPath, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+func writeManifest(backupDir string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(backupDir, manifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+	return nil
+}
+
+// repoRelativePath makes file relative to root and rejects paths outside
+// it. Relative paths are taken to be relative to root already.
+func repoRelativePath(root, file string) (string, error) {
+	if filepath.IsAbs(file) {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return "", err
+		}
+		rel, err := filepath.Rel(abs, file)
+		if err != nil {
+			return "", err
+		}
+		file = rel
+	}
+	return safeRelativePath(filepath.ToSlash(file))
+}
+
+func safeRelativePath(path string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(path))
+	if filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path is outside the repository")
+	}
+	return cleaned, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
 func copyFile(src, dst string) error {
 	source, err := os.Open(src)
 	if err != nil {
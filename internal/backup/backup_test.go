@@ -0,0 +1,124 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// The repository is never the working directory: every path is resolved
+// against root.
+func TestBackupAndRestoreOutsideRoot(t *testing.T) {
+	for _, loc := range []Location{LocationRepo, LocationState} {
+		t.Run(string(loc), func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			root := t.TempDir()
+			ci := filepath.Join(".forgejo", "workflows", "ci.yml")
+			writeFile(t, filepath.Join(root, ci), "original\n")
+
+			path, err := CreateBackup(root, []string{ci}, "v1.0.0", loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loc == LocationRepo {
+				if rel, _ := filepath.Rel(root, path); filepath.Dir(rel) != filepath.Join(".forgejo", "workflows") {
+					t.Errorf("repo backup created at %s, want under .forgejo/workflows", rel)
+				}
+			}
+
+			backups, err := ListBackups(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(backups) != 1 || backups[0].Path != path || backups[0].Location != loc {
+				t.Fatalf("ListBackups = %+v, want the new backup", backups)
+			}
+			if other, _ := ListBackups(t.TempDir()); len(other) != 0 {
+				t.Errorf("backup listed for another repository: %+v", other)
+			}
+
+			writeFile(t, filepath.Join(root, ci), "pinned\n")
+			b, err := FindBackup(root, "latest")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := RestoreFiles(root, b.Path, []string{filepath.Join(root, ci)}); err != nil {
+				t.Fatal(err)
+			}
+			if got := readFile(t, filepath.Join(root, ci)); got != "original\n" {
+				t.Errorf("restored content = %q", got)
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsFilesOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	ci := filepath.Join(".github", "workflows", "ci.yml")
+	writeFile(t, filepath.Join(root, ci), "on: push\n")
+
+	path, err := CreateBackup(root, []string{ci}, "v1.0.0", LocationRepo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"../ci.yml", filepath.Join(t.TempDir(), "ci.yml")} {
+		if err := RestoreFiles(root, path, []string{file}); err == nil {
+			t.Errorf("RestoreFiles accepted %s", file)
+		}
+	}
+}
+
+func TestSelectFiles(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	root := t.TempDir()
+	ci := filepath.Join(".github", "workflows", "ci.yml")
+	lint := filepath.Join(".github", "workflows", "lint.yml")
+	writeFile(t, filepath.Join(root, ci), "on: push\n")
+	writeFile(t, filepath.Join(root, lint), "on: push\n")
+
+	path, err := CreateBackup(root, []string{ci, lint}, "v1.0.0", LocationState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := FindBackup(root, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, arg := range []string{"./" + ci, filepath.Join(root, ci), filepath.Join(".github", "..", ci)} {
+		files, err := b.SelectFiles(root, []string{arg})
+		if err != nil {
+			t.Errorf("SelectFiles(%s): %v", arg, err)
+			continue
+		}
+		if len(files) != 1 || files[0].Path != ci {
+			t.Errorf("SelectFiles(%s) = %+v, want %s", arg, files, ci)
+		}
+	}
+
+	if files, err := b.SelectFiles(root, nil); err != nil || len(files) != 2 {
+		t.Errorf("SelectFiles(nil) = %+v, %v; want every file", files, err)
+	}
+	if _, err := b.SelectFiles(root, []string{"release.yml"}); err == nil {
+		t.Error("SelectFiles accepted a file that is not in the backup")
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type AppCredentials struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     []byte
+}
+
+func GetAppCredentials(appID, installationID int64, privateKeyPath string) (*AppCredentials, error) {
+	if appID == 0 {
+		appID, _ = strconv.ParseInt(os.Getenv("GHA_FREEZE_APP_ID"), 10, 64)
+	}
+	if installationID == 0 {
+		installationID, _ = strconv.ParseInt(os.Getenv("GHA_FREEZE_APP_INSTALLATION_ID"), 10, 64)
+	}
+
+	var key []byte
+	if privateKeyPath != "" {
+		data, err := os.ReadFile(privateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read app private key: %w", err)
+		}
+		key = data
+	} else if envKey := os.Getenv("GHA_FREEZE_APP_PRIVATE_KEY"); envKey != "" {
+		if strings.HasPrefix(strings.TrimSpace(envKey), "-----BEGIN") {
+			key = []byte(envKey)
+		} else {
+			data, err := os.ReadFile(envKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read app private key: %w", err)
+			}
+			key = data
+		}
+	}
+
+	if appID == 0 && installationID == 0 && key == nil {
+		return nil, nil
+	}
+
+	if appID == 0 || installationID == 0 || key == nil {
+		return nil, fmt.Errorf("GitHub App authentication requires an app ID, installation ID and private key")
+	}
+
+	return &AppCredentials{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKey:     key,
+	}, nil
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 const (
@@ -10,6 +11,15 @@ const (
 	configFile = "token"
 )
 
+const (
+	SourceNone   = ""
+	SourceFlag   = "--token flag"
+	SourceEnvGH  = "GITHUB_TOKEN"
+	SourceEnvGHA = "GHA_FREEZE_TOKEN"
+	SourceFile   = "token file"
+	SourceGHCLI  = "gh CLI (hosts.yml)"
+)
+
 func GetTokenPath() (string, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -29,7 +39,7 @@ func SaveToken(token string) error {
 		return err
 	}
 
-	return os.WriteFile(path, []byte(token), 0600)
+	return os.WriteFile(path, []byte(strings.TrimSpace(token)), 0600)
 }
 
 func LoadToken() (string, error) {
@@ -46,22 +56,69 @@ func LoadToken() (string, error) {
 		return "", err
 	}
 
-	return string(data), nil
+	return strings.TrimSpace(string(data)), nil
+}
+
+func DeleteToken() (bool, error) {
+	path, err := GetTokenPath()
+	if err != nil {
+		return false, err
+	}
+
+	if err := os.Remove(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
 }
 
 func GetToken(providedToken string) string {
+	token, _ := ResolveToken(providedToken)
+	return token
+}
+
+func ResolveToken(providedToken string) (string, string) {
+	return ResolveTokenForHost(providedToken, tokenHost())
+}
+
+// ResolveTokenForHost returns the token to send to host and where it came
+// from. The flag, environment and saved tokens belong to the default host
+// (GH_HOST, or github.com); any other host only gets its own gh CLI entry,
+// so a token is never sent to a server it was not issued for.
+func ResolveTokenForHost(providedToken, host string) (string, string) {
+	if host == "" {
+		return "", SourceNone
+	}
+
+	if host != tokenHost() {
+		if ghToken, _ := LoadGHToken(host); ghToken != "" {
+			return ghToken, SourceGHCLI
+		}
+		return "", SourceNone
+	}
+
 	if providedToken != "" {
-		return providedToken
+		return providedToken, SourceFlag
 	}
 
 	if envToken := os.Getenv("GITHUB_TOKEN"); envToken != "" {
-		return envToken
+		return envToken, SourceEnvGH
 	}
 
 	if envToken := os.Getenv("GHA_FREEZE_TOKEN"); envToken != "" {
-		return envToken
+		return envToken, SourceEnvGHA
+	}
+
+	if savedToken, _ := LoadToken(); savedToken != "" {
+		return savedToken, SourceFile
+	}
+
+	if ghToken, _ := LoadGHToken(host); ghToken != "" {
+		return ghToken, SourceGHCLI
 	}
 
-	savedToken, _ := LoadToken()
-	return savedToken
+	return "", SourceNone
 }
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultHost = "github.com"
+
+type ghHostEntry struct {
+	User       string `yaml:"user"`
+	OAuthToken string `yaml:"oauth_token"`
+	Users      map[string]struct {
+		OAuthToken string `yaml:"oauth_token"`
+	} `yaml:"users"`
+}
+
+func GHConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("AppData"); dir != "" {
+			return filepath.Join(dir, "GitHub CLI")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh")
+}
+
+func LoadGHToken(host string) (string, error) {
+	dir := GHConfigDir()
+	if dir == "" {
+		return "", nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	var hosts map[string]ghHostEntry
+	if err := yaml.Unmarshal(data, &hosts); err != nil {
+		return "", err
+	}
+
+	entry, ok := hosts[host]
+	if !ok {
+		return "", nil
+	}
+
+	if entry.OAuthToken != "" {
+		return entry.OAuthToken, nil
+	}
+
+	// Newer gh versions nest tokens per account; the active one is "user".
+	if u, ok := entry.Users[entry.User]; ok {
+		return u.OAuthToken, nil
+	}
+
+	return "", nil
+}
+
+func tokenHost() string {
+	if host := os.Getenv("GH_HOST"); host != "" {
+		return host
+	}
+	return defaultHost
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// isolate points every token source at empty or fixture locations.
+func isolate(t *testing.T, ghDir string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GH_CONFIG_DIR", ghDir)
+	t.Setenv("GH_HOST", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GHA_FREEZE_TOKEN", "")
+}
+
+func TestLoadGHToken(t *testing.T) {
+	tests := []struct {
+		dir  string
+		host string
+		want string
+	}{
+		{"gh-legacy", "github.com", "gho_legacy"},
+		{"gh-multi", "github.com", "gho_hubot"},
+		{"gh-multi", "ghe.example.com", "gho_enterprise"},
+		{"gh-multi", "other.example.com", ""},
+		{"missing", "github.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir+"/"+tt.host, func(t *testing.T) {
+			isolate(t, filepath.Join("testdata", tt.dir))
+			got, err := LoadGHToken(tt.host)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("LoadGHToken(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTokenFallsBackToGHCLI(t *testing.T) {
+	isolate(t, filepath.Join("testdata", "gh-multi"))
+
+	token, source := ResolveToken("")
+	if token != "gho_hubot" || source != SourceGHCLI {
+		t.Errorf("ResolveToken() = %q, %q; want gho_hubot from %s", token, source, SourceGHCLI)
+	}
+
+	t.Setenv("GH_HOST", "ghe.example.com")
+	if token, _ := ResolveToken(""); token != "gho_enterprise" {
+		t.Errorf("with GH_HOST, ResolveToken() = %q, want gho_enterprise", token)
+	}
+}
+
+func TestResolveTokenPrefersEarlierSources(t *testing.T) {
+	isolate(t, filepath.Join("testdata", "gh-legacy"))
+
+	if err := SaveToken("saved\n"); err != nil {
+		t.Fatal(err)
+	}
+	if token, source := ResolveToken(""); token != "saved" || source != SourceFile {
+		t.Errorf("ResolveToken() = %q, %q; want the saved token", token, source)
+	}
+
+	t.Setenv("GITHUB_TOKEN", "from-env")
+	if token, source := ResolveToken(""); token != "from-env" || source != SourceEnvGH {
+		t.Errorf("ResolveToken() = %q, %q; want GITHUB_TOKEN", token, source)
+	}
+
+	if token, source := ResolveToken("from-flag"); token != "from-flag" || source != SourceFlag {
+		t.Errorf("ResolveToken() = %q, %q; want the flag", token, source)
+	}
+
+	if _, err := os.Stat(filepath.Join(os.Getenv("HOME"), configDir, configFile)); err != nil {
+		t.Errorf("saved token not written under HOME: %v", err)
+	}
+}
+
+func TestResolveTokenForOtherHost(t *testing.T) {
+	isolate(t, filepath.Join("testdata", "gh-multi"))
+	t.Setenv("GITHUB_TOKEN", "from-env")
+
+	if token, source := ResolveTokenForHost("from-flag", "ghe.example.com"); token != "gho_enterprise" || source != SourceGHCLI {
+		t.Errorf("ResolveTokenForHost(ghe) = %q, %q; want its gh CLI token", token, source)
+	}
+	if token, _ := ResolveTokenForHost("from-flag", "other.example.com"); token != "" {
+		t.Errorf("ResolveTokenForHost(other) = %q, want no token", token)
+	}
+	if token, _ := ResolveTokenForHost("from-flag", ""); token != "" {
+		t.Errorf("ResolveTokenForHost(\"\") = %q, want no token", token)
+	}
+	if token, _ := ResolveTokenForHost("from-flag", "github.com"); token != "from-flag" {
+		t.Errorf("ResolveTokenForHost(github.com) = %q, want the flag", token)
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	settingsFile    = "config.yml"
+	updateCheckFile = "update-check.json"
+	stateDir        = ".local/state/gha-freeze"
+)
+
+type Settings struct {
+	BackupLocation      string       `yaml:"backup_location"`
+	UpdateChannel       string       `yaml:"update_channel"`
+	UpdateCheckInterval string       `yaml:"update_check_interval"`
+	UpdateSource        UpdateSource `yaml:"update_source"`
+	// Host is a GitHub Enterprise Server, Gitea or Forgejo host to resolve
+	// actions against; HostType is auto, github or gitea.
+	Host         string   `yaml:"host"`
+	HostType     string   `yaml:"host_type"`
+	WorkflowDirs []string `yaml:"workflow_dirs"`
+	// Resolver is api (the default) or git, which lists refs over the git
+	// protocol and falls back to the API.
+	Resolver string `yaml:"resolver"`
+}
+
+// UpdateSource overrides where self-updates come from: either a repository
+// on GitHub (Enterprise Server) or the base URL of a release mirror.
+type UpdateSource struct {
+	Repo   string `yaml:"repo"`
+	APIURL string `yaml:"api_url"`
+	URL    string `yaml:"url"`
+}
+
+func GetSettingsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, configDir, settingsFile), nil
+}
+
+func GetUpdateCheckPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, configDir, updateCheckFile), nil
+}
+
+func LoadSettings() (Settings, error) {
+	var settings Settings
+
+	path, err := GetSettingsPath()
+	if err != nil {
+		return settings, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return settings, nil
+		}
+		return settings, err
+	}
+
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return settings, err
+	}
+	return settings, nil
+}
+
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "gha-freeze"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, stateDir), nil
+}
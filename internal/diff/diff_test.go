@@ -0,0 +1,172 @@
+package diff
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fileChange struct {
+	path     string
+	old, new string
+}
+
+// applyPatch checks patch with git apply in a repository holding the old
+// contents, applies it and compares the result with the new contents.
+func applyPatch(t *testing.T, patch string, files []fileChange) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL="+os.DevNull)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s\npatch:\n%s", strings.Join(args, " "), err, out, patch)
+		}
+	}
+	git("init", "-q")
+
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f.path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.old), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "change.patch"), []byte(patch), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	git("apply", "--check", "change.patch")
+	git("apply", "change.patch")
+
+	for _, f := range files {
+		got, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(f.path)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != f.new {
+			t.Errorf("%s after git apply = %q, want %q", f.path, got, f.new)
+		}
+	}
+}
+
+func lines(n int, change map[int]string) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		if l, ok := change[i]; ok {
+			b.WriteString(l)
+		} else {
+			b.WriteString("line " + string(rune('a'+i%26)) + "\n")
+		}
+	}
+	return b.String()
+}
+
+func TestGitPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []fileChange
+		hunks int
+	}{
+		{
+			name: "first and last line",
+			files: []fileChange{{
+				path: ".github/workflows/ci.yml",
+				old:  lines(20, nil),
+				new:  lines(20, map[int]string{1: "first\n", 20: "last\n"}),
+			}},
+			hunks: 2,
+		},
+		{
+			name: "no trailing newline",
+			files: []fileChange{{
+				path: "ci.yml",
+				old:  "on: push\n  - uses: actions/checkout@v4",
+				new:  "on: push\n  - uses: actions/checkout@abc # v4",
+			}},
+			hunks: 1,
+		},
+		{
+			name: "newline added at end of file",
+			files: []fileChange{{
+				path: "ci.yml",
+				old:  "on: push\nuses: a@v1",
+				new:  "on: push\nuses: a@v1\n",
+			}},
+			hunks: 1,
+		},
+		{
+			name: "nearby changes merge",
+			files: []fileChange{{
+				path: "ci.yml",
+				old:  lines(30, nil),
+				new:  lines(30, map[int]string{10: "ten\n", 15: "fifteen\n"}),
+			}},
+			hunks: 1,
+		},
+		{
+			name: "distant changes stay apart",
+			files: []fileChange{{
+				path: "ci.yml",
+				old:  lines(30, nil),
+				new:  lines(30, map[int]string{5: "five\n", 25: "twenty-five\n"}),
+			}},
+			hunks: 2,
+		},
+		{
+			name: "several files",
+			files: []fileChange{
+				{path: ".github/workflows/ci.yml", old: "uses: a@v1\n", new: "uses: a@sha # v1\n"},
+				{path: ".gitea/workflows/lint.yml", old: lines(8, nil), new: lines(8, map[int]string{4: "four\n"})},
+			},
+			hunks: 2,
+		},
+		{
+			name: "path with spaces",
+			files: []fileChange{{
+				path: ".github/workflows/build and test.yml",
+				old:  "uses: a@v1\n",
+				new:  "uses: a@sha # v1\n",
+			}},
+			hunks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch strings.Builder
+			for _, f := range tt.files {
+				patch.WriteString(GitPatch(f.path, []byte(f.old), []byte(f.new)))
+			}
+
+			if got := strings.Count(patch.String(), "\n@@ "); got != tt.hunks {
+				t.Errorf("patch has %d hunks, want %d:\n%s", got, tt.hunks, patch.String())
+			}
+			applyPatch(t, patch.String(), tt.files)
+		})
+	}
+}
+
+func TestGitPatchNoNewlineMarker(t *testing.T) {
+	patch := GitPatch("ci.yml", []byte("a\nb"), []byte("a\nc"))
+	want := "-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n"
+	if !strings.HasSuffix(patch, want) {
+		t.Errorf("patch does not end with the no-newline markers:\n%s", patch)
+	}
+}
+
+func TestGitPatchUnchanged(t *testing.T) {
+	if patch := GitPatch("ci.yml", []byte("a\n"), []byte("a\n")); patch != "" {
+		t.Errorf("GitPatch of identical contents = %q, want empty", patch)
+	}
+}
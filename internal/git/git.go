@@ -0,0 +1,198 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+type Repo struct {
+	Dir string
+}
+
+func Open(dir string) (*Repo, error) {
+	r := &Repo{Dir: dir}
+	if _, err := r.run("rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("not a git repository: %w", err)
+	}
+	return r, nil
+}
+
+var ErrNotRepository = errors.New("not a git repository")
+
+// FindRoot walks up from dir to the top of the working tree. Worktrees and
+// submodules have a .git file instead of a directory, so either counts.
+func FindRoot(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+
+	for d := abs; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d, nil
+		}
+
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", fmt.Errorf("%w (or any of the parent directories): %s", ErrNotRepository, abs)
+		}
+		d = parent
+	}
+}
+
+func (r *Repo) DirtyFiles(files []string) ([]string, error) {
+	args := append([]string{"status", "--porcelain", "--"}, files...)
+	out, err := r.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirty []string
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 3 {
+			dirty = append(dirty, strings.TrimSpace(line[3:]))
+		}
+	}
+	return dirty, nil
+}
+
+func (r *Repo) CurrentBranch() (string, error) {
+	return r.run("rev-parse", "--abbrev-ref", "HEAD")
+}
+
+// Head returns what to check out to get back here: the current branch, or
+// the commit when HEAD is detached.
+func (r *Repo) Head() (string, error) {
+	branch, err := r.CurrentBranch()
+	if err != nil || branch != "HEAD" {
+		return branch, err
+	}
+	return r.run("rev-parse", "HEAD")
+}
+
+// CheckNewBranch fails if name is not a valid branch name or already
+// exists, so callers can bail out before touching the working tree.
+func (r *Repo) CheckNewBranch(name string) error {
+	if _, err := r.run("check-ref-format", "--branch", name); err != nil {
+		return fmt.Errorf("invalid branch name %q", name)
+	}
+	if _, err := r.run("rev-parse", "--verify", "--quiet", "refs/heads/"+name); err == nil {
+		return fmt.Errorf("branch %s already exists", name)
+	}
+	return nil
+}
+
+// CreateBranch creates name at HEAD and checks it out, carrying over any
+// uncommitted changes.
+func (r *Repo) CreateBranch(name string) error {
+	if err := r.CheckNewBranch(name); err != nil {
+		return err
+	}
+	if _, err := r.run("checkout", "-b", name); err != nil {
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// AbandonBranch switches back to previous and deletes name.
+func (r *Repo) AbandonBranch(name, previous string) error {
+	if _, err := r.run("checkout", previous); err != nil {
+		return fmt.Errorf("failed to switch back to %s: %w", previous, err)
+	}
+	if _, err := r.run("branch", "-D", name); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w", name, err)
+	}
+	return nil
+}
+
+func (r *Repo) Commit(files []string, message string) (string, error) {
+	args := append([]string{"add", "--"}, files...)
+	if _, err := r.run(args...); err != nil {
+		return "", fmt.Errorf("failed to stage files: %w", err)
+	}
+
+	args = append([]string{"commit", "-m", message, "--"}, files...)
+	if _, err := r.run(args...); err != nil {
+		// Leave the index as it was, e.g. when a hook rejects the commit.
+		_, _ = r.run(append([]string{"reset", "-q", "--"}, files...)...)
+		return "", fmt.Errorf("failed to commit: %w", err)
+	}
+
+	return r.run("rev-parse", "HEAD")
+}
+
+func (r *Repo) RemoteURL(name string) (string, error) {
+	out, err := r.run("remote", "get-url", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read remote %s: %w", name, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ParseRemote extracts the host, owner and repository name from a remote
+// URL in any of the forms git accepts: https://host/owner/repo.git,
+// ssh://git@host/owner/repo.git or git@host:owner/repo.git.
+func ParseRemote(remote string) (host, owner, repo string, err error) {
+	var p string
+	if u, perr := url.Parse(remote); perr == nil && u.Scheme != "" && u.Host != "" {
+		host, p = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remote, ":"); ok && !strings.Contains(at, "/") {
+		if i := strings.LastIndex(at, "@"); i >= 0 {
+			at = at[i+1:]
+		}
+		host, p = at, rest
+	} else {
+		return "", "", "", fmt.Errorf("unrecognized remote URL %q", remote)
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimSuffix(p, ".git"), "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] == "" || parts[len(parts)-1] == "" {
+		return "", "", "", fmt.Errorf("remote URL %q does not name a repository", remote)
+	}
+	return host, parts[len(parts)-2], parts[len(parts)-1], nil
+}
+
+func (r *Repo) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+func CommitMessage(replacements []workflow.Replacement) string {
+	var b strings.Builder
+	b.WriteString("Pin GitHub Actions to commit SHAs\n\n")
+	for _, repl := range replacements {
+		b.WriteString(fmt.Sprintf("- %s/%s@%s → %s # %s (%s)\n",
+			repl.Action.Owner, repl.Action.Repo, repl.Action.Ref,
+			repl.SHA, repl.Version, repl.Action.FilePath))
+	}
+	b.WriteString("\nGenerated by gha-freeze.\n")
+	return b.String()
+}
@@ -0,0 +1,162 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+// newTestRepo creates a repository on branch main with one committed
+// workflow file.
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	writeFile(t, filepath.Join(dir, ".github", "workflows", "ci.yml"), "on: push\n")
+	gitRun(t, dir, "add", ".")
+	gitRun(t, dir, "commit", "-q", "-m", "initial")
+	return &Repo{Dir: dir}
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindRoot(t *testing.T) {
+	repo := newTestRepo(t)
+	root, _ := filepath.EvalSymlinks(repo.Dir)
+
+	sub := filepath.Join(root, ".github", "workflows")
+	for _, dir := range []string{root, sub} {
+		got, err := FindRoot(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != root {
+			t.Errorf("FindRoot(%s) = %s, want %s", dir, got, root)
+		}
+	}
+
+	// A worktree has a .git file rather than a directory.
+	wt := filepath.Join(t.TempDir(), "wt")
+	gitRun(t, root, "worktree", "add", "-q", wt)
+	if got, err := FindRoot(filepath.Join(wt, ".github")); err != nil || got != wt {
+		t.Errorf("FindRoot(worktree) = %s, %v; want %s", got, err, wt)
+	}
+
+	if _, err := FindRoot(t.TempDir()); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("FindRoot(outside) error = %v, want ErrNotRepository", err)
+	}
+}
+
+func TestCreateBranchAndCommit(t *testing.T) {
+	repo := newTestRepo(t)
+	file := filepath.Join(".github", "workflows", "ci.yml")
+
+	if err := repo.CheckNewBranch("main"); err == nil {
+		t.Error("CheckNewBranch(main) succeeded for an existing branch")
+	}
+	if err := repo.CheckNewBranch("bad..name"); err == nil {
+		t.Error("CheckNewBranch accepted an invalid name")
+	}
+
+	writeFile(t, filepath.Join(repo.Dir, file), "on: pull_request\n")
+	dirty, err := repo.DirtyFiles([]string{file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirty) != 1 || dirty[0] != filepath.ToSlash(file) {
+		t.Errorf("DirtyFiles = %v, want [%s]", dirty, file)
+	}
+
+	if err := repo.CreateBranch("pin-actions"); err != nil {
+		t.Fatal(err)
+	}
+	msg := CommitMessage([]workflow.Replacement{{
+		Action:  workflow.ActionReference{Owner: "actions", Repo: "checkout", Ref: "v4", FilePath: file},
+		SHA:     "0123456789012345678901234567890123456789",
+		Version: "v4",
+	}})
+	sha, err := repo.Commit([]string{file}, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if branch, _ := repo.CurrentBranch(); branch != "pin-actions" {
+		t.Errorf("CurrentBranch = %s, want pin-actions", branch)
+	}
+	if head := gitRun(t, repo.Dir, "rev-parse", "main"); head == sha {
+		t.Error("commit landed on main")
+	}
+	if body := gitRun(t, repo.Dir, "log", "-1", "--format=%B"); !strings.Contains(body, "actions/checkout@v4 → 0123456789012345678901234567890123456789") {
+		t.Errorf("commit message does not list the pin:\n%s", body)
+	}
+}
+
+func TestCommitFailureLeavesIndexAlone(t *testing.T) {
+	repo := newTestRepo(t)
+	file := filepath.Join(".github", "workflows", "ci.yml")
+	writeFile(t, filepath.Join(repo.Dir, ".git", "hooks", "pre-commit"), "#!/bin/sh\nexit 1\n")
+	if err := os.Chmod(filepath.Join(repo.Dir, ".git", "hooks", "pre-commit"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateBranch("pin-actions"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(repo.Dir, file), "on: pull_request\n")
+
+	if _, err := repo.Commit([]string{file}, "pin"); err == nil {
+		t.Fatal("Commit succeeded despite the failing hook")
+	}
+	if staged := gitRun(t, repo.Dir, "diff", "--cached", "--name-only"); staged != "" {
+		t.Errorf("files left staged: %s", staged)
+	}
+
+	gitRun(t, repo.Dir, "checkout", "-q", "--", file)
+	if err := repo.AbandonBranch("pin-actions", previous); err != nil {
+		t.Fatal(err)
+	}
+	if branch, _ := repo.CurrentBranch(); branch != "main" {
+		t.Errorf("CurrentBranch = %s, want main", branch)
+	}
+	if err := repo.CheckNewBranch("pin-actions"); err != nil {
+		t.Errorf("pin-actions still exists: %v", err)
+	}
+}
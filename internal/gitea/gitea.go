@@ -0,0 +1,210 @@
+// Package gitea resolves action refs against the REST API of a Gitea or
+// Forgejo instance, whose Actions use the same uses: syntax as GitHub.
+package gitea
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+)
+
+const apiPath = "/api/v1"
+
+// Resolver implements github.Resolver. Refs are tried as a tag, then a
+// branch, then a commit, the same order the Actions runner uses.
+type Resolver struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// NewResolver returns a resolver for the instance at host, which is either a
+// hostname (https is assumed) or a base URL such as https://git.example.com.
+func NewResolver(host, token string, httpClient *http.Client) *Resolver {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Resolver{
+		baseURL:    apiURL(host),
+		token:      token,
+		httpClient: httpClient,
+	}
+}
+
+// Detect reports whether host answers the Gitea version endpoint, which
+// Forgejo also serves.
+func Detect(ctx context.Context, host string, httpClient *http.Client) bool {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	r := &Resolver{baseURL: apiURL(host), httpClient: httpClient}
+
+	var v struct {
+		Version string `json:"version"`
+	}
+	status, err := r.get(ctx, "/version", &v)
+	return err == nil && status == http.StatusOK && v.Version != ""
+}
+
+func (r *Resolver) Resolve(ctx context.Context, owner, repo, ref string) github.ResolvedAction {
+	repoPath := "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+
+	var tag struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	status, err := r.get(ctx, repoPath+"/tags/"+url.PathEscape(ref), &tag)
+	if err != nil {
+		return github.ResolvedAction{Error: err}
+	}
+	if status == http.StatusOK && tag.Commit.SHA != "" {
+		return github.ResolvedAction{SHA: tag.Commit.SHA, Version: ref}
+	}
+	if status != http.StatusNotFound {
+		return github.ResolvedAction{Error: statusError(status)}
+	}
+
+	var branch struct {
+		Commit struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+	}
+	status, err = r.get(ctx, repoPath+"/branches/"+url.PathEscape(ref), &branch)
+	if err != nil {
+		return github.ResolvedAction{Error: err}
+	}
+	if status == http.StatusOK && branch.Commit.ID != "" {
+		return github.ResolvedAction{SHA: branch.Commit.ID, Version: ref}
+	}
+	if status != http.StatusNotFound {
+		return github.ResolvedAction{Error: statusError(status)}
+	}
+
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	status, err = r.get(ctx, repoPath+"/git/commits/"+url.PathEscape(ref), &commit)
+	if err != nil {
+		return github.ResolvedAction{Error: err}
+	}
+	if status == http.StatusOK && commit.SHA != "" {
+		return github.ResolvedAction{SHA: commit.SHA, Version: ref}
+	}
+	// Gitea answers 422 for a malformed commit SHA.
+	if status != http.StatusNotFound && status != http.StatusUnprocessableEntity {
+		return github.ResolvedAction{Error: statusError(status)}
+	}
+
+	return github.ResolvedAction{Error: r.explainNotFound(ctx, repoPath, ref)}
+}
+
+// SetToken replaces the token sent to the instance.
+func (r *Resolver) SetToken(token string) {
+	r.token = token
+}
+
+// ValidateToken checks token against the instance's current user endpoint.
+// Gitea does not report rate limits, so only Login is set.
+func (r *Resolver) ValidateToken(ctx context.Context, token string) (*github.TokenInfo, error) {
+	check := &Resolver{baseURL: r.baseURL, token: token, httpClient: r.httpClient}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	status, err := check.get(ctx, "/user", &user)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", r.baseURL+"/user", http.StatusText(status))
+	}
+	return &github.TokenInfo{Login: user.Login}, nil
+}
+
+// ListVersions lists the repository's tags, newest first, for the version
+// picker.
+func (r *Resolver) ListVersions(ctx context.Context, owner, repo string) ([]github.Version, error) {
+	var tags []struct {
+		Name string `json:"name"`
+	}
+	status, err := r.get(ctx, "/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/tags?limit=30", &tags)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, statusError(status)
+	}
+
+	versions := make([]github.Version, 0, len(tags))
+	for _, t := range tags {
+		versions = append(versions, github.Version{Tag: t.Name})
+	}
+	return versions, nil
+}
+
+// explainNotFound mirrors the GitHub resolver: a repository that cannot be
+// read at all is reported as private rather than as a missing ref.
+func (r *Resolver) explainNotFound(ctx context.Context, repoPath, ref string) error {
+	err := fmt.Errorf("no tag, branch or commit named %q", ref)
+	status, repoErr := r.get(ctx, repoPath, nil)
+	if repoErr == nil && status == http.StatusNotFound {
+		return &github.ResolveError{Reason: github.FailurePrivate, Err: err}
+	}
+	return &github.ResolveError{Reason: github.FailureNotFound, Err: err}
+}
+
+// get fetches path below the API root and decodes a 200 response into v.
+// Other statuses are returned for the caller to interpret.
+func (r *Resolver) get(ctx context.Context, path string, v any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "token "+r.token)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK || v == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return resp.StatusCode, nil
+}
+
+func statusError(status int) error {
+	err := fmt.Errorf("unexpected response: %s", http.StatusText(status))
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &github.ResolveError{Reason: github.FailurePermission, Err: err}
+	case http.StatusTooManyRequests:
+		return &github.ResolveError{Reason: github.FailureRateLimit, Err: err}
+	}
+	return &github.ResolveError{Reason: github.FailureUnknown, Err: err}
+}
+
+func apiURL(host string) string {
+	host = strings.TrimSuffix(host, "/")
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return strings.TrimSuffix(host, apiPath) + apiPath
+}
@@ -0,0 +1,177 @@
+package gitea
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/lsremote"
+)
+
+const (
+	tagSHA    = "1111111111111111111111111111111111111111"
+	branchSHA = "2222222222222222222222222222222222222222"
+	commitSHA = "3333333333333333333333333333333333333333"
+)
+
+// fakeGitea serves actions/checkout publicly and actions/internal only to
+// requests with the token "secret".
+type fakeGitea struct {
+	auth []string // Authorization headers received
+}
+
+func (f *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.auth = append(f.auth, r.Header.Get("Authorization"))
+	authorized := r.Header.Get("Authorization") == "token secret"
+
+	path, ok := strings.CutPrefix(r.URL.Path, apiPath)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch path {
+	case "/version":
+		fmt.Fprint(w, `{"version":"1.22.0"}`)
+	case "/user":
+		if !authorized {
+			http.Error(w, `{"message":"token is required"}`, http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"login":"forgejo-bot"}`)
+	case "/repos/actions/checkout", "/repos/actions/setup-go":
+		fmt.Fprint(w, `{}`)
+	case "/repos/actions/checkout/tags/v4":
+		fmt.Fprintf(w, `{"commit":{"sha":%q}}`, tagSHA)
+	case "/repos/actions/checkout/branches/main":
+		fmt.Fprintf(w, `{"commit":{"id":%q}}`, branchSHA)
+	case "/repos/actions/checkout/git/commits/" + commitSHA:
+		fmt.Fprintf(w, `{"sha":%q}`, commitSHA)
+	case "/repos/actions/checkout/git/commits/nope":
+		http.Error(w, `{"message":"invalid sha"}`, http.StatusUnprocessableEntity)
+	case "/repos/actions/checkout/tags":
+		fmt.Fprint(w, `[{"name":"v4"},{"name":"v3"}]`)
+	case "/repos/actions/internal/tags/v1":
+		if !authorized {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `{"commit":{"sha":%q}}`, tagSHA)
+	case "/repos/actions/limited/tags/v1":
+		http.Error(w, `{"message":"forbidden"}`, http.StatusForbidden)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newFakeGitea(t *testing.T) (*fakeGitea, *httptest.Server) {
+	t.Helper()
+	f := &fakeGitea{}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func TestResolve(t *testing.T) {
+	_, srv := newFakeGitea(t)
+	r := NewResolver(srv.URL, "", nil)
+
+	tests := []struct {
+		repo, ref string
+		sha       string
+		reason    string
+	}{
+		{"checkout", "v4", tagSHA, ""},
+		{"checkout", "main", branchSHA, ""},
+		{"checkout", commitSHA, commitSHA, ""},
+		{"checkout", "nope", "", github.FailureNotFound},
+		{"setup-go", "v9", "", github.FailureNotFound},
+		{"internal", "v1", "", github.FailurePrivate},
+		{"limited", "v1", "", github.FailurePermission},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repo+"@"+tt.ref, func(t *testing.T) {
+			got := r.Resolve(t.Context(), "actions", tt.repo, tt.ref)
+			if tt.reason == "" {
+				if got.Error != nil || got.SHA != tt.sha || got.Version != tt.ref {
+					t.Errorf("Resolve = %+v, want %s", got, tt.sha)
+				}
+				return
+			}
+			var resolveErr *github.ResolveError
+			if !errors.As(got.Error, &resolveErr) || resolveErr.Reason != tt.reason {
+				t.Errorf("Resolve error = %v, want reason %s", got.Error, tt.reason)
+			}
+		})
+	}
+}
+
+func TestListVersions(t *testing.T) {
+	_, srv := newFakeGitea(t)
+	versions, err := NewResolver(srv.URL, "", nil).ListVersions(t.Context(), "actions", "checkout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Tag != "v4" || versions[1].Tag != "v3" {
+		t.Errorf("ListVersions = %+v", versions)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	_, srv := newFakeGitea(t)
+	if !Detect(t.Context(), srv.URL, nil) {
+		t.Error("Detect() = false for a Gitea API")
+	}
+
+	other := httptest.NewServer(http.NotFoundHandler())
+	defer other.Close()
+	if Detect(t.Context(), other.URL, nil) {
+		t.Error("Detect() = true for a server without the version endpoint")
+	}
+}
+
+func TestAPIURL(t *testing.T) {
+	for host, want := range map[string]string{
+		"codeberg.org":                   "https://codeberg.org/api/v1",
+		"https://git.example.com/":       "https://git.example.com/api/v1",
+		"http://localhost:3000/api/v1":   "http://localhost:3000/api/v1",
+		"https://git.example.com/gitea/": "https://git.example.com/gitea/api/v1",
+	} {
+		if got := apiURL(host); got != want {
+			t.Errorf("apiURL(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+// A token entered after a rate limit must reach the Gitea resolver, also
+// when it sits behind the git protocol resolver.
+func TestClientTokenReachesResolver(t *testing.T) {
+	f, srv := newFakeGitea(t)
+	gitea := NewResolver(srv.URL, "", nil)
+	client := github.NewClient("", github.WithResolver(lsremote.NewResolver(srv.URL, gitea, nil)))
+
+	if got := client.ResolveAction("actions", "internal", "v1"); got.Error == nil {
+		t.Fatalf("resolved a private action without a token: %+v", got)
+	}
+
+	if _, err := client.ValidateToken("wrong"); err == nil {
+		t.Error("ValidateToken accepted a token Gitea rejects")
+	}
+	info, err := client.ValidateToken("secret")
+	if err != nil || info.Login != "forgejo-bot" {
+		t.Fatalf("ValidateToken = %+v, %v; want it validated by Gitea", info, err)
+	}
+
+	client.SetToken("secret")
+	if got := client.ResolveAction("actions", "internal", "v1"); got.Error != nil || got.SHA != tagSHA {
+		t.Errorf("ResolveAction after SetToken = %+v, want %s", got, tagSHA)
+	}
+	if last := f.auth[len(f.auth)-1]; last != "token secret" {
+		t.Errorf("last request sent Authorization %q", last)
+	}
+}
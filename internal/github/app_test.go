@@ -0,0 +1,153 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testKey(t *testing.T) (*rsa.PrivateKey, []byte) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// verifyJWT checks an RS256 JWT against key and returns its claims.
+func verifyJWT(t *testing.T, token string, key *rsa.PublicKey) map[string]any {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("malformed JWT %q", token)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		t.Fatalf("JWT signature: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestAppTransport(t *testing.T) {
+	key, keyPEM := testKey(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var minted atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		jwt, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			t.Errorf("token request without a JWT")
+		}
+		claims := verifyJWT(t, jwt, &key.PublicKey)
+		if claims["iss"] != "7" {
+			t.Errorf("iss = %v, want 7", claims["iss"])
+		}
+
+		n := minted.Add(1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"ghs_%d","expires_at":%q}`, n, now.Add(time.Hour).Format(time.RFC3339))
+	})
+	mux.HandleFunc("GET /rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	transport, err := NewAppTransport(7, 42, keyPEM, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport.now = func() time.Time { return now }
+	client := &http.Client{Transport: transport}
+
+	get := func() string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + "/rate_limit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimPrefix(string(body), "token ")
+	}
+
+	if auth := get(); auth != "ghs_1" {
+		t.Errorf("Authorization = token %q, want ghs_1", auth)
+	}
+	if auth := get(); auth != "ghs_1" || minted.Load() != 1 {
+		t.Errorf("token not reused: %q after %d mints", auth, minted.Load())
+	}
+
+	// Within refreshBefore of expiry a new token is minted.
+	now = now.Add(56 * time.Minute)
+	if auth := get(); auth != "ghs_2" {
+		t.Errorf("Authorization = token %q after refresh, want ghs_2", auth)
+	}
+}
+
+func TestAppTransportTokenError(t *testing.T) {
+	_, keyPEM := testKey(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	transport, err := NewAppTransport(7, 42, keyPEM, srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transport.Token(t.Context()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Token() error = %v, want a 401", err)
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key, pkcs1 := testKey(t)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	for name, data := range map[string][]byte{"pkcs1": pkcs1, "pkcs8": pkcs8} {
+		if _, err := parsePrivateKey(data); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+
+	if _, err := parsePrivateKey([]byte("not a key")); err == nil {
+		t.Error("expected an error for data without a PEM block")
+	}
+}
@@ -2,36 +2,138 @@ package github
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/google/go-github/v58/github"
 )
 
 type Client struct {
-	client *github.Client
-	ctx    context.Context
-	token  string
+	client   *github.Client
+	ctx      context.Context
+	token    string
+	baseURL  string
+	resolver Resolver
 }
 
-func NewClient(token string) *Client {
-	ctx := context.Background()
-	var client *github.Client
+// Resolver resolves action refs somewhere other than the GitHub REST API,
+// e.g. on a Gitea or Forgejo instance. A resolver that falls back to another
+// one exposes it with an Unwrap() Resolver method.
+type Resolver interface {
+	Resolve(ctx context.Context, owner, repo, ref string) ResolvedAction
+}
 
-	if token != "" {
-		client = github.NewClient(nil).WithAuthToken(token)
-	} else {
-		client = github.NewClient(nil)
+// ResolverFunc adapts a function, such as Client.ResolveREST, to Resolver.
+type ResolverFunc func(ctx context.Context, owner, repo, ref string) ResolvedAction
+
+func (f ResolverFunc) Resolve(ctx context.Context, owner, repo, ref string) ResolvedAction {
+	return f(ctx, owner, repo, ref)
+}
+
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	httpClient *http.Client
+	baseURL    string
+	resolver   Resolver
+}
+
+func WithAppTransport(t *AppTransport) Option {
+	return func(o *clientOptions) {
+		o.httpClient = &http.Client{Transport: t}
 	}
+}
 
-	return &Client{
-		client: client,
-		ctx:    ctx,
-		token:  token,
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = httpClient
 	}
 }
 
+func WithBaseURL(baseURL string) Option {
+	return func(o *clientOptions) {
+		o.baseURL = baseURL
+	}
+}
+
+func WithResolver(r Resolver) Option {
+	return func(o *clientOptions) {
+		o.resolver = r
+	}
+}
+
+func NewClient(token string, opts ...Option) *Client {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c := &Client{
+		ctx:      context.Background(),
+		token:    token,
+		baseURL:  o.baseURL,
+		resolver: o.resolver,
+	}
+	c.client = newGitHubClient(o.httpClient, o.baseURL, token)
+	return c
+}
+
+func newGitHubClient(httpClient *http.Client, baseURL, token string) *github.Client {
+	client := github.NewClient(httpClient)
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+	if baseURL != "" {
+		if !strings.HasSuffix(baseURL, "/") {
+			baseURL += "/"
+		}
+		if u, err := url.Parse(baseURL); err == nil {
+			client.BaseURL = u
+		}
+	}
+	return client
+}
+
+// SetToken replaces the token, including the one a resolver such as Gitea
+// sends to its own host.
 func (c *Client) SetToken(token string) {
 	c.token = token
-	c.client = github.NewClient(nil).WithAuthToken(token)
+	c.client = newGitHubClient(nil, c.baseURL, token)
+	if auth, ok := findResolver[Authenticator](c.resolver); ok {
+		auth.SetToken(token)
+	}
+}
+
+// ForwardsToken reports whether SetToken hands the token to a resolver for
+// another host rather than the GitHub API. Such a token must not be stored
+// as the GitHub token.
+func (c *Client) ForwardsToken() bool {
+	_, ok := findResolver[Authenticator](c.resolver)
+	return ok
+}
+
+// findResolver returns the first resolver in r's chain that implements T,
+// looking through resolvers such as the git protocol one to the resolver
+// they fall back to.
+func findResolver[T any](r Resolver) (T, bool) {
+	for r != nil {
+		if t, ok := r.(T); ok {
+			return t, true
+		}
+		u, ok := r.(interface{ Unwrap() Resolver })
+		if !ok {
+			break
+		}
+		r = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// SetResolver replaces how actions are resolved; nil restores the REST API.
+func (c *Client) SetResolver(r Resolver) {
+	c.resolver = r
 }
 
 func (c *Client) GetClient() *github.Client {
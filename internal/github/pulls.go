@@ -0,0 +1,207 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v58/github"
+)
+
+const DefaultPRBranch = "gha-freeze/pin-actions"
+
+// commitMarker ends the message of every commit OpenPullRequest creates. A
+// branch whose head carries it was written by gha-freeze and may be replaced.
+const commitMarker = "Generated by gha-freeze."
+
+type FileUpdate struct {
+	Path    string
+	Content []byte
+}
+
+type PullRequestOptions struct {
+	Owner         string
+	Repo          string
+	Base          string // defaults to the repository's default branch
+	Branch        string // defaults to DefaultPRBranch
+	Title         string
+	Body          string
+	CommitMessage string
+	Files         []FileUpdate
+}
+
+type PullRequestResult struct {
+	Number    int
+	URL       string
+	CommitSHA string
+	Updated   bool
+}
+
+// OpenPullRequest commits files on top of the base branch with the Git Data
+// API and opens a pull request from Branch, or updates the one that is
+// already open. Branch is force-updated, so re-running replaces the previous
+// commit rather than stacking on it; an existing branch is only reused if
+// gha-freeze created it. No local clone or git credentials are involved.
+func (c *Client) OpenPullRequest(ctx context.Context, opts PullRequestOptions) (*PullRequestResult, error) {
+	client := c.GetClient()
+
+	if opts.Branch == "" {
+		opts.Branch = DefaultPRBranch
+	}
+	if opts.Base == "" {
+		repo, _, err := client.Repositories.Get(ctx, opts.Owner, opts.Repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get repository: %w", err)
+		}
+		opts.Base = repo.GetDefaultBranch()
+	}
+	if opts.Base == opts.Branch {
+		return nil, fmt.Errorf("pull request branch %q is the base branch", opts.Branch)
+	}
+
+	if !strings.Contains(opts.CommitMessage, commitMarker) {
+		opts.CommitMessage = strings.TrimRight(opts.CommitMessage, "\n") + "\n\n" + commitMarker + "\n"
+	}
+
+	exists, err := c.checkBranch(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	baseRef, _, err := client.Git.GetRef(ctx, opts.Owner, opts.Repo, "heads/"+opts.Base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base branch %s: %w", opts.Base, err)
+	}
+	baseSHA := baseRef.GetObject().GetSHA()
+
+	baseCommit, _, err := client.Git.GetCommit(ctx, opts.Owner, opts.Repo, baseSHA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get base commit: %w", err)
+	}
+
+	entries := make([]*github.TreeEntry, 0, len(opts.Files))
+	for _, f := range opts.Files {
+		blob, _, err := client.Git.CreateBlob(ctx, opts.Owner, opts.Repo, &github.Blob{
+			Content:  github.String(string(f.Content)),
+			Encoding: github.String("utf-8"),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload %s: %w", f.Path, err)
+		}
+		entries = append(entries, &github.TreeEntry{
+			Path: github.String(f.Path),
+			Mode: github.String("100644"),
+			Type: github.String("blob"),
+			SHA:  blob.SHA,
+		})
+	}
+
+	tree, _, err := client.Git.CreateTree(ctx, opts.Owner, opts.Repo, baseCommit.GetTree().GetSHA(), entries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tree: %w", err)
+	}
+
+	commit, _, err := client.Git.CreateCommit(ctx, opts.Owner, opts.Repo, &github.Commit{
+		Message: github.String(opts.CommitMessage),
+		Tree:    tree,
+		Parents: []*github.Commit{{SHA: github.String(baseSHA)}},
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create commit: %w", err)
+	}
+
+	if err := c.pointBranch(ctx, opts.Owner, opts.Repo, opts.Branch, commit.GetSHA(), exists); err != nil {
+		return nil, err
+	}
+
+	existing, _, err := client.PullRequests.List(ctx, opts.Owner, opts.Repo, &github.PullRequestListOptions{
+		State: "open",
+		Head:  opts.Owner + ":" + opts.Branch,
+		Base:  opts.Base,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pull requests: %w", err)
+	}
+
+	if len(existing) > 0 {
+		pr, _, err := client.PullRequests.Edit(ctx, opts.Owner, opts.Repo, existing[0].GetNumber(), &github.PullRequest{
+			Title: github.String(opts.Title),
+			Body:  github.String(opts.Body),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to update pull request #%d: %w", existing[0].GetNumber(), err)
+		}
+		return &PullRequestResult{Number: pr.GetNumber(), URL: pr.GetHTMLURL(), CommitSHA: commit.GetSHA(), Updated: true}, nil
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, opts.Owner, opts.Repo, &github.NewPullRequest{
+		Title: github.String(opts.Title),
+		Head:  github.String(opts.Branch),
+		Base:  github.String(opts.Base),
+		Body:  github.String(opts.Body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request: %w", err)
+	}
+	return &PullRequestResult{Number: pr.GetNumber(), URL: pr.GetHTMLURL(), CommitSHA: commit.GetSHA()}, nil
+}
+
+// checkBranch reports whether opts.Branch exists, and refuses to go on if it
+// does but its head is not a gha-freeze commit. Anything else may be
+// someone's work, such as a fix pushed on top of the gha-freeze commit of an
+// open pull request, which force-updating the branch would throw away.
+func (c *Client) checkBranch(ctx context.Context, opts PullRequestOptions) (bool, error) {
+	client := c.GetClient()
+
+	ref, resp, err := client.Git.GetRef(ctx, opts.Owner, opts.Repo, "heads/"+opts.Branch)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get branch %s: %w", opts.Branch, err)
+	}
+
+	head, _, err := client.Git.GetCommit(ctx, opts.Owner, opts.Repo, ref.GetObject().GetSHA())
+	if err != nil {
+		return false, fmt.Errorf("failed to get head of branch %s: %w", opts.Branch, err)
+	}
+	if !strings.Contains(head.GetMessage(), commitMarker) {
+		return false, fmt.Errorf("refusing to overwrite branch %s: it was not created by gha-freeze or has commits on top of it", opts.Branch)
+	}
+	return true, nil
+}
+
+func (c *Client) pointBranch(ctx context.Context, owner, repo, branch, sha string, exists bool) error {
+	client := c.GetClient()
+	ref := &github.Reference{
+		Ref:    github.String("refs/heads/" + branch),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}
+
+	if !exists {
+		if _, _, err := client.Git.CreateRef(ctx, owner, repo, ref); err != nil {
+			return fmt.Errorf("failed to create branch %s: %w", branch, err)
+		}
+		return nil
+	}
+
+	if _, _, err := client.Git.UpdateRef(ctx, owner, repo, ref, true); err != nil {
+		return fmt.Errorf("failed to update branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// WebURL is the base URL of the GitHub web UI that matches the API the
+// client talks to.
+func (c *Client) WebURL() string {
+	if c.baseURL == "" {
+		return "https://github.com"
+	}
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "https://github.com"
+	}
+	return u.Scheme + "://" + u.Host
+}
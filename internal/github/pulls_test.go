@@ -0,0 +1,212 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGitAPI implements the Git Data and Pulls endpoints OpenPullRequest
+// uses for a single repository, owner/repo.
+type fakeGitAPI struct {
+	mu       sync.Mutex
+	branches map[string]string // branch -> head SHA
+	messages map[string]string // commit SHA -> message
+	prs      []fakePR
+	blobs    []string
+	updated  []string // branches force-updated
+}
+
+type fakePR struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Branch string `json:"-"`
+	Base   string `json:"-"`
+}
+
+func newFakeGitAPI() *fakeGitAPI {
+	return &fakeGitAPI{
+		branches: map[string]string{"main": "base"},
+		messages: map[string]string{"base": "Initial commit"},
+	}
+}
+
+func (f *fakeGitAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path, ok := strings.CutPrefix(r.URL.Path, "/api/v3/repos/owner/repo/")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body map[string]any
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	reply := func(v any) { _ = json.NewEncoder(w).Encode(v) }
+	ref := func(branch string) map[string]any {
+		return map[string]any{"ref": "refs/heads/" + branch, "object": map[string]any{"sha": f.branches[branch]}}
+	}
+
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "git/ref/heads/"):
+		branch := strings.TrimPrefix(path, "git/ref/heads/")
+		if _, ok := f.branches[branch]; !ok {
+			http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+			return
+		}
+		reply(ref(branch))
+
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "git/commits/"):
+		sha := strings.TrimPrefix(path, "git/commits/")
+		reply(map[string]any{"sha": sha, "message": f.messages[sha], "tree": map[string]any{"sha": "tree-" + sha}})
+
+	case r.Method == http.MethodPost && path == "git/blobs":
+		f.blobs = append(f.blobs, body["content"].(string))
+		reply(map[string]any{"sha": fmt.Sprintf("blob-%d", len(f.blobs))})
+
+	case r.Method == http.MethodPost && path == "git/trees":
+		reply(map[string]any{"sha": "tree-new"})
+
+	case r.Method == http.MethodPost && path == "git/commits":
+		sha := fmt.Sprintf("commit-%d", len(f.messages))
+		f.messages[sha] = body["message"].(string)
+		reply(map[string]any{"sha": sha, "tree": map[string]any{"sha": "tree-new"}})
+
+	case r.Method == http.MethodPost && path == "git/refs":
+		branch := strings.TrimPrefix(body["ref"].(string), "refs/heads/")
+		f.branches[branch] = body["sha"].(string)
+		reply(ref(branch))
+
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "git/refs/heads/"):
+		branch := strings.TrimPrefix(path, "git/refs/heads/")
+		f.branches[branch] = body["sha"].(string)
+		f.updated = append(f.updated, branch)
+		reply(ref(branch))
+
+	case r.Method == http.MethodGet && path == "pulls":
+		head := strings.TrimPrefix(r.URL.Query().Get("head"), "owner:")
+		base := r.URL.Query().Get("base")
+		prs := []fakePR{}
+		for _, pr := range f.prs {
+			if pr.Branch == head && (base == "" || pr.Base == base) {
+				prs = append(prs, pr)
+			}
+		}
+		reply(prs)
+
+	case r.Method == http.MethodPost && path == "pulls":
+		pr := fakePR{Number: len(f.prs) + 1, Title: body["title"].(string), Branch: body["head"].(string), Base: body["base"].(string)}
+		f.prs = append(f.prs, pr)
+		reply(pr)
+
+	case r.Method == http.MethodPatch && strings.HasPrefix(path, "pulls/"):
+		n, _ := strconv.Atoi(strings.TrimPrefix(path, "pulls/"))
+		f.prs[n-1].Title = body["title"].(string)
+		reply(f.prs[n-1])
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeGitAPI) client(t *testing.T) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return NewClient("", WithBaseURL(srv.URL+"/api/v3/"))
+}
+
+func pullRequestOptions(branch string) PullRequestOptions {
+	return PullRequestOptions{
+		Owner:         "owner",
+		Repo:          "repo",
+		Base:          "main",
+		Branch:        branch,
+		Title:         "Pin GitHub Actions to commit SHAs",
+		CommitMessage: "Pin GitHub Actions to commit SHAs",
+		Files:         []FileUpdate{{Path: ".github/workflows/ci.yml", Content: []byte("pinned\n")}},
+	}
+}
+
+func TestOpenPullRequest(t *testing.T) {
+	f := newFakeGitAPI()
+	client := f.client(t)
+
+	first, err := client.OpenPullRequest(t.Context(), pullRequestOptions(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Updated || first.Number != 1 || f.branches[DefaultPRBranch] != first.CommitSHA {
+		t.Fatalf("first run = %+v, branches %v; want PR #1 from a new %s", first, f.branches, DefaultPRBranch)
+	}
+	if len(f.blobs) != 1 || f.blobs[0] != "pinned\n" {
+		t.Errorf("uploaded blobs %q", f.blobs)
+	}
+	if !strings.HasSuffix(f.messages[first.CommitSHA], commitMarker+"\n") {
+		t.Errorf("commit message %q does not end with the gha-freeze marker", f.messages[first.CommitSHA])
+	}
+
+	// A second run replaces the gha-freeze commit and updates the PR.
+	second, err := client.OpenPullRequest(t.Context(), pullRequestOptions(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Updated || second.Number != 1 || len(f.prs) != 1 {
+		t.Errorf("second run = %+v with %d PRs, want PR #1 updated", second, len(f.prs))
+	}
+	if len(f.updated) != 1 || f.branches[DefaultPRBranch] != second.CommitSHA {
+		t.Errorf("branch not force-updated to %s: %v", second.CommitSHA, f.branches)
+	}
+}
+
+func TestOpenPullRequestExistingBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		prTitle string
+		wantErr bool
+	}{
+		{"gha-freeze commit", "Pin GitHub Actions to commit SHAs\n\n" + commitMarker + "\n", "", false},
+		// A human commit on top of a gha-freeze commit, with the gha-freeze
+		// pull request still open, must survive.
+		{"commit on top of gha-freeze", "Fix review comment", "Pin GitHub Actions to commit SHAs", true},
+		{"someone else's PR", "Fix review comment", "Add feature", true},
+		{"no PR", "Work in progress", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeGitAPI()
+			f.branches["pin"] = "theirs"
+			f.messages["theirs"] = tt.message
+			if tt.prTitle != "" {
+				f.prs = append(f.prs, fakePR{Number: 1, Title: tt.prTitle, Branch: "pin", Base: "main"})
+			}
+
+			_, err := f.client(t).OpenPullRequest(t.Context(), pullRequestOptions("pin"))
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "not created by gha-freeze") {
+					t.Fatalf("OpenPullRequest error = %v, want a refusal", err)
+				}
+				if f.branches["pin"] != "theirs" || len(f.blobs) != 0 {
+					t.Errorf("branch moved to %s or blobs uploaded (%d) despite the refusal", f.branches["pin"], len(f.blobs))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.branches["pin"] == "theirs" {
+				t.Error("gha-freeze branch was not updated")
+			}
+		})
+	}
+}
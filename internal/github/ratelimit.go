@@ -1,10 +1,18 @@
 package github
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/google/go-github/v58/github"
 )
 
+// defaultSecondaryWait is used when a secondary rate limit response does
+// not say how long to back off.
+const defaultSecondaryWait = time.Minute
+
 type RateLimitError struct {
 	Remaining int
 	Limit     int
@@ -22,6 +30,29 @@ func IsRateLimitError(err error) bool {
 	return strings.Contains(errMsg, "rate limit") || strings.Contains(errMsg, "403")
 }
 
+// RateLimitWait reports how long to wait before retrying a request that
+// failed with err, if err is a primary or secondary rate limit error.
+func RateLimitWait(err error) (time.Duration, bool) {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		wait := time.Until(rateErr.Rate.Reset.Time) + time.Second
+		if wait < time.Second {
+			wait = time.Second
+		}
+		return wait, true
+	}
+
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return defaultSecondaryWait, true
+	}
+
+	return 0, false
+}
+
 func (c *Client) CheckRateLimit() (*RateLimitStatus, error) {
 	ctx := c.GetContext()
 	client := c.GetClient()
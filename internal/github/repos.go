@@ -0,0 +1,133 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v58/github"
+)
+
+type Repository struct {
+	Owner         string
+	Name          string
+	DefaultBranch string
+	Archived      bool
+	Fork          bool
+}
+
+func (r Repository) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+type WorkflowFile struct {
+	Path    string
+	Content []byte
+}
+
+func (c *Client) GetRepository(ctx context.Context, owner, name string) (Repository, error) {
+	r, _, err := c.GetClient().Repositories.Get(ctx, owner, name)
+	if err != nil {
+		return Repository{}, fmt.Errorf("failed to get repository %s/%s: %w", owner, name, err)
+	}
+	return toRepository(r), nil
+}
+
+// ListOrgRepos returns every repository in org visible to the current
+// credentials.
+func (c *Client) ListOrgRepos(ctx context.Context, org string) ([]Repository, error) {
+	opts := &github.RepositoryListByOrgOptions{
+		Type:        "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var repos []Repository
+	for {
+		page, resp, err := c.GetClient().Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of %s: %w", org, err)
+		}
+
+		for _, r := range page {
+			repos = append(repos, toRepository(r))
+		}
+
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+func toRepository(r *github.Repository) Repository {
+	return Repository{
+		Owner:         r.GetOwner().GetLogin(),
+		Name:          r.GetName(),
+		DefaultBranch: r.GetDefaultBranch(),
+		Archived:      r.GetArchived(),
+		Fork:          r.GetFork(),
+	}
+}
+
+// FetchWorkflows downloads the YAML files directly under dir at ref. A
+// repository without that directory has no workflows, which is not an error.
+func (c *Client) FetchWorkflows(ctx context.Context, owner, repo, ref, dir string) ([]WorkflowFile, error) {
+	client := c.GetClient()
+	opts := &github.RepositoryContentGetOptions{Ref: ref}
+
+	_, entries, resp, err := client.Repositories.GetContents(ctx, owner, repo, dir, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var files []WorkflowFile
+	for _, entry := range entries {
+		if entry.GetType() != "file" {
+			continue
+		}
+		if ext := path.Ext(entry.GetName()); ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+
+		content, err := c.FetchFile(ctx, owner, repo, ref, entry.GetPath())
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		files = append(files, WorkflowFile{
+			Path:    strings.TrimPrefix(entry.GetPath(), "/"),
+			Content: content,
+		})
+	}
+
+	return files, nil
+}
+
+// FetchFile downloads the file at path on ref. It returns nil content if
+// path does not exist or is not a file.
+func (c *Client) FetchFile(ctx context.Context, owner, repo, ref, path string) ([]byte, error) {
+	opts := &github.RepositoryContentGetOptions{Ref: ref}
+	file, _, resp, err := c.GetClient().Repositories.GetContents(ctx, owner, repo, path, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	if file == nil {
+		return nil, nil
+	}
+
+	content, err := file.GetContent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return []byte(content), nil
+}
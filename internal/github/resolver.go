@@ -1,8 +1,14 @@
 package github
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"strings"
+
+	"github.com/google/go-github/v58/github"
 )
 
 type ResolvedAction struct {
@@ -11,8 +17,29 @@ type ResolvedAction struct {
 	Error   error
 }
 
+const (
+	FailureNotFound   = "not found"
+	FailurePrivate    = "private or inaccessible repository"
+	FailurePermission = "permission denied"
+	FailureNetwork    = "network error"
+	FailureRateLimit  = "rate limited"
+	FailureUnknown    = "unknown error"
+)
+
 func (c *Client) ResolveAction(owner, repo, ref string) ResolvedAction {
-	ctx := c.GetContext()
+	return c.ResolveActionContext(c.GetContext(), owner, repo, ref)
+}
+
+func (c *Client) ResolveActionContext(ctx context.Context, owner, repo, ref string) ResolvedAction {
+	if c.resolver != nil {
+		return c.resolver.Resolve(ctx, owner, repo, ref)
+	}
+	return c.ResolveREST(ctx, owner, repo, ref)
+}
+
+// ResolveREST resolves through the REST API even when another Resolver is
+// configured, e.g. as its fallback.
+func (c *Client) ResolveREST(ctx context.Context, owner, repo, ref string) ResolvedAction {
 	client := c.GetClient()
 
 	if strings.HasPrefix(ref, "v") {
@@ -20,7 +47,7 @@ func (c *Client) ResolveAction(owner, repo, ref string) ResolvedAction {
 		gitRef, resp, err := client.Git.GetRef(ctx, owner, repo, tagRef)
 		if err != nil {
 			if resp != nil && resp.StatusCode == 404 {
-				return c.resolveAsBranch(owner, repo, ref)
+				return c.resolveAsBranch(ctx, owner, repo, ref)
 			}
 			return ResolvedAction{Error: err}
 		}
@@ -33,15 +60,17 @@ func (c *Client) ResolveAction(owner, repo, ref string) ResolvedAction {
 		}
 	}
 
-	return c.resolveAsBranch(owner, repo, ref)
+	return c.resolveAsBranch(ctx, owner, repo, ref)
 }
 
-func (c *Client) resolveAsBranch(owner, repo, branch string) ResolvedAction {
-	ctx := c.GetContext()
+func (c *Client) resolveAsBranch(ctx context.Context, owner, repo, branch string) ResolvedAction {
 	client := c.GetClient()
 
-	commit, _, err := client.Repositories.GetCommit(ctx, owner, repo, branch, nil)
+	commit, resp, err := client.Repositories.GetCommit(ctx, owner, repo, branch, nil)
 	if err != nil {
+		if resp != nil && (resp.StatusCode == 404 || resp.StatusCode == 422) {
+			return ResolvedAction{Error: c.explainNotFound(ctx, owner, repo, err)}
+		}
 		return ResolvedAction{Error: err}
 	}
 
@@ -54,3 +83,65 @@ func (c *Client) resolveAsBranch(owner, repo, branch string) ResolvedAction {
 
 	return ResolvedAction{Error: fmt.Errorf("unable to resolve reference")}
 }
+
+// explainNotFound distinguishes a missing ref from a repository that does not
+// exist or is hidden from the current credentials, since GitHub answers 404 for both.
+func (c *Client) explainNotFound(ctx context.Context, owner, repo string, err error) error {
+	_, resp, repoErr := c.GetClient().Repositories.Get(ctx, owner, repo)
+	if repoErr != nil && resp != nil && resp.StatusCode == 404 {
+		return &ResolveError{Reason: FailurePrivate, Err: err}
+	}
+	return &ResolveError{Reason: FailureNotFound, Err: err}
+}
+
+type ResolveError struct {
+	Reason string
+	Err    error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Reason, e.Err)
+}
+
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+func ClassifyResolveError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var resolveErr *ResolveError
+	if errors.As(err, &resolveErr) {
+		return resolveErr.Reason
+	}
+
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateErr) || errors.As(err, &abuseErr) {
+		return FailureRateLimit
+	}
+
+	var respErr *github.ErrorResponse
+	if errors.As(err, &respErr) && respErr.Response != nil {
+		switch respErr.Response.StatusCode {
+		case 404, 422:
+			return FailureNotFound
+		case 401, 403:
+			return FailurePermission
+		}
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+	if errors.As(err, &netErr) || errors.As(err, &urlErr) {
+		return FailureNetwork
+	}
+
+	if IsRateLimitError(err) {
+		return FailureRateLimit
+	}
+
+	return FailureUnknown
+}
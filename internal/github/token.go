@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type TokenInfo struct {
+	Login     string
+	Scopes    []string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Authenticator is implemented by resolvers that send a token of their own,
+// such as the Gitea resolver. The client hands SetToken and ValidateToken to
+// them instead of the GitHub API.
+type Authenticator interface {
+	SetToken(token string)
+	ValidateToken(ctx context.Context, token string) (*TokenInfo, error)
+}
+
+// ValidateToken checks token against the API the client resolves with: the
+// configured GitHub or GitHub Enterprise Server API, or the resolver's own
+// host if it authenticates itself.
+func (c *Client) ValidateToken(token string) (*TokenInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if auth, ok := findResolver[Authenticator](c.resolver); ok {
+		return auth.ValidateToken(ctx, token)
+	}
+
+	client := newGitHubClient(nil, c.baseURL, token)
+	user, resp, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	info := &TokenInfo{
+		Login:     user.GetLogin(),
+		Limit:     resp.Rate.Limit,
+		Remaining: resp.Rate.Remaining,
+		Reset:     resp.Rate.Reset.Time,
+	}
+
+	for _, scope := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			info.Scopes = append(info.Scopes, scope)
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeAuthenticator records the token the client hands to it.
+type fakeAuthenticator struct {
+	token string
+}
+
+func (a *fakeAuthenticator) Resolve(ctx context.Context, owner, repo, ref string) ResolvedAction {
+	return ResolvedAction{SHA: a.token}
+}
+
+func (a *fakeAuthenticator) SetToken(token string) { a.token = token }
+
+func (a *fakeAuthenticator) ValidateToken(ctx context.Context, token string) (*TokenInfo, error) {
+	return &TokenInfo{Login: "via-" + token}, nil
+}
+
+// wrapper falls back to another resolver, like the git protocol resolver.
+type wrapper struct{ fallback Resolver }
+
+func (w wrapper) Resolve(ctx context.Context, owner, repo, ref string) ResolvedAction {
+	return w.fallback.Resolve(ctx, owner, repo, ref)
+}
+
+func (w wrapper) Unwrap() Resolver { return w.fallback }
+
+func TestValidateTokenUsesBaseURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/user" || r.Header.Get("Authorization") != "Bearer ghe_token" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-OAuth-Scopes", "repo, workflow")
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		w.Header().Set("X-RateLimit-Remaining", "4999")
+		fmt.Fprint(w, `{"login":"octocat"}`)
+	}))
+	defer srv.Close()
+
+	client := NewClient("", WithBaseURL(srv.URL+"/api/v3/"))
+	info, err := client.ValidateToken("ghe_token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Login != "octocat" || len(info.Scopes) != 2 || info.Remaining != 4999 {
+		t.Errorf("ValidateToken = %+v", info)
+	}
+	if _, err := client.ValidateToken("wrong"); err == nil {
+		t.Error("ValidateToken accepted a token the server rejects")
+	}
+}
+
+func TestSetTokenReachesResolver(t *testing.T) {
+	auth := &fakeAuthenticator{}
+	client := NewClient("", WithResolver(wrapper{auth}))
+	if !client.ForwardsToken() {
+		t.Fatal("ForwardsToken() = false with an authenticating resolver")
+	}
+
+	client.SetToken("gitea_token")
+	if got := client.ResolveAction("owner", "repo", "v1").SHA; got != "gitea_token" {
+		t.Errorf("resolver token = %q, want gitea_token", got)
+	}
+	if info, err := client.ValidateToken("other"); err != nil || info.Login != "via-other" {
+		t.Errorf("ValidateToken = %+v, %v; want it validated by the resolver", info, err)
+	}
+
+	if NewClient("").ForwardsToken() {
+		t.Error("ForwardsToken() = true without a resolver")
+	}
+}
@@ -0,0 +1,67 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v58/github"
+)
+
+// VersionLister is implemented by resolvers that can also list an action's
+// versions for the version picker.
+type VersionLister interface {
+	ListVersions(ctx context.Context, owner, repo string) ([]Version, error)
+}
+
+type Version struct {
+	Tag         string
+	PublishedAt time.Time
+	Latest      bool
+	Prerelease  bool
+}
+
+func (c *Client) ListVersions(owner, repo string) ([]Version, error) {
+	ctx := c.GetContext()
+	if lister, ok := findResolver[VersionLister](c.resolver); ok {
+		return lister.ListVersions(ctx, owner, repo)
+	}
+
+	client := c.GetClient()
+
+	releases, _, err := client.Repositories.ListReleases(ctx, owner, repo, &github.ListOptions{PerPage: 30})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(releases) > 0 {
+		latestTag := ""
+		if latest, _, err := client.Repositories.GetLatestRelease(ctx, owner, repo); err == nil {
+			latestTag = latest.GetTagName()
+		}
+
+		var versions []Version
+		for _, r := range releases {
+			if r.GetDraft() {
+				continue
+			}
+			versions = append(versions, Version{
+				Tag:         r.GetTagName(),
+				PublishedAt: r.GetPublishedAt().Time,
+				Latest:      r.GetTagName() == latestTag,
+				Prerelease:  r.GetPrerelease(),
+			})
+		}
+		return versions, nil
+	}
+
+	tags, _, err := client.Repositories.ListTags(ctx, owner, repo, &github.ListOptions{PerPage: 30})
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]Version, 0, len(tags))
+	for _, t := range tags {
+		versions = append(versions, Version{Tag: t.GetName()})
+	}
+	return versions, nil
+}
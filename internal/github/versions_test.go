@@ -0,0 +1,29 @@
+package github
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeLister struct{}
+
+func (fakeLister) Resolve(ctx context.Context, owner, repo, ref string) ResolvedAction {
+	return ResolvedAction{}
+}
+
+func (fakeLister) ListVersions(ctx context.Context, owner, repo string) ([]Version, error) {
+	return []Version{{Tag: "v2"}, {Tag: "v1"}}, nil
+}
+
+// A resolver wrapped by another, such as Gitea behind the git protocol
+// resolver, still lists the versions.
+func TestListVersionsFromWrappedResolver(t *testing.T) {
+	client := NewClient("", WithResolver(wrapper{fakeLister{}}))
+	versions, err := client.ListVersions("owner", "repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].Tag != "v2" {
+		t.Errorf("ListVersions = %+v, want the wrapped resolver's tags", versions)
+	}
+}
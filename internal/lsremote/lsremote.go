@@ -0,0 +1,201 @@
+// Package lsremote lists the refs of a remote repository the way
+// `git ls-remote` does, using the smart HTTP protocol v2 ls-refs command.
+// Git hosts serve this outside their REST API and its rate limits.
+package lsremote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// userAgent starts with "git/" because some hosts only speak protocol v2
+// to clients that look like git.
+const userAgent = "git/2.0 (gha-freeze)"
+
+// Ref is one advertised ref. Peeled is the commit an annotated tag points
+// to and is empty for everything else.
+type Ref struct {
+	Name   string
+	SHA    string
+	Peeled string
+}
+
+// Commit is the commit the ref ultimately points to.
+func (r Ref) Commit() string {
+	if r.Peeled != "" {
+		return r.Peeled
+	}
+	return r.SHA
+}
+
+// ListRefs returns the refs of the repository at remote whose names start
+// with one of prefixes. http(s) remotes are queried with ls-refs; file://
+// remotes and local paths are handed to git ls-remote.
+func ListRefs(ctx context.Context, httpClient *http.Client, remote string, prefixes ...string) ([]Ref, error) {
+	u, err := url.Parse(remote)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return listHTTP(ctx, httpClient, remote, prefixes)
+	}
+	return listLocal(ctx, remote, prefixes)
+}
+
+func listHTTP(ctx context.Context, httpClient *http.Client, remote string, prefixes []string) ([]Ref, error) {
+	var body bytes.Buffer
+	writePkt(&body, "command=ls-refs\n")
+	body.WriteString("0001")
+	writePkt(&body, "peel\n")
+	for _, p := range prefixes {
+		writePkt(&body, "ref-prefix "+p+"\n")
+	}
+	body.WriteString("0000")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(remote, "/")+"/git-upload-pack", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-git-upload-pack-request")
+	req.Header.Set("Accept", "application/x-git-upload-pack-result")
+	req.Header.Set("Git-Protocol", "version=2")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ls-refs %s: %s", remote, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-git-upload-pack-result" {
+		return nil, fmt.Errorf("ls-refs %s: unexpected content type %q", remote, ct)
+	}
+
+	return parseLsRefs(bufio.NewReader(resp.Body))
+}
+
+// parseLsRefs reads "<oid> <name>[ attr]..." pkt-lines up to the flush
+// packet.
+func parseLsRefs(r *bufio.Reader) ([]Ref, error) {
+	var refs []Ref
+	for {
+		line, flush, err := readPkt(r)
+		if err != nil {
+			return nil, err
+		}
+		if flush {
+			return refs, nil
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if msg, ok := strings.CutPrefix(line, "ERR "); ok {
+			return nil, fmt.Errorf("remote error: %s", msg)
+		}
+
+		fields := strings.Split(line, " ")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed ls-refs line %q", line)
+		}
+		ref := Ref{SHA: fields[0], Name: fields[1]}
+		for _, attr := range fields[2:] {
+			if peeled, ok := strings.CutPrefix(attr, "peeled:"); ok {
+				ref.Peeled = peeled
+			}
+		}
+		refs = append(refs, ref)
+	}
+}
+
+func listLocal(ctx context.Context, remote string, prefixes []string) ([]Ref, error) {
+	out, err := runGit(exec.CommandContext(ctx, "git", "ls-remote", remote))
+	if err != nil {
+		return nil, err
+	}
+
+	// Annotated tags are followed by a "<name>^{}" line with the commit.
+	var refs []Ref
+	index := make(map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		sha, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if tag, peeled := strings.CutSuffix(name, "^{}"); peeled {
+			if i, ok := index[tag]; ok {
+				refs[i].Peeled = sha
+			}
+			continue
+		}
+		if hasPrefix(name, prefixes) {
+			index[name] = len(refs)
+			refs = append(refs, Ref{Name: name, SHA: sha})
+		}
+	}
+	return refs, nil
+}
+
+func runGit(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git ls-remote: %s", msg)
+		}
+		return "", fmt.Errorf("git ls-remote: %w", err)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+func hasPrefix(name string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func writePkt(w *bytes.Buffer, line string) {
+	fmt.Fprintf(w, "%04x%s", len(line)+4, line)
+}
+
+// readPkt reads one pkt-line. flush is true for the flush packet "0000".
+func readPkt(r *bufio.Reader) (line string, flush bool, err error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return "", false, io.ErrUnexpectedEOF
+		}
+		return "", false, err
+	}
+
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil {
+		return "", false, fmt.Errorf("malformed pkt-line length %q", size[:])
+	}
+	switch {
+	case n == 0:
+		return "", true, nil
+	case n < 4:
+		return "", false, fmt.Errorf("unexpected special packet %04x", n)
+	}
+
+	data := make([]byte, n-4)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", false, err
+	}
+	return string(data), false, nil
+}
@@ -0,0 +1,240 @@
+package lsremote
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+)
+
+// testRemote is actions/checkout under root, a directory of bare
+// repositories laid out like a git host.
+type testRemote struct {
+	root     string
+	first    string // commit of tag v3 and branch release
+	second   string // commit of tags v4, v4.1 and release, branch main and v5
+	tagV4SHA string // the annotated tag object of v4
+}
+
+func newTestRemote(t *testing.T) *testRemote {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	work := t.TempDir()
+	r := &testRemote{root: t.TempDir()}
+
+	gitRun(t, work, "init", "-q", "-b", "main")
+	gitRun(t, work, "commit", "-q", "--allow-empty", "-m", "first")
+	r.first = gitRun(t, work, "rev-parse", "HEAD")
+	gitRun(t, work, "tag", "v3")
+	gitRun(t, work, "branch", "release")
+
+	gitRun(t, work, "commit", "-q", "--allow-empty", "-m", "second")
+	r.second = gitRun(t, work, "rev-parse", "HEAD")
+	gitRun(t, work, "tag", "-a", "v4", "-m", "v4")
+	r.tagV4SHA = gitRun(t, work, "rev-parse", "v4")
+	gitRun(t, work, "tag", "v4.1")
+	gitRun(t, work, "tag", "release")
+	gitRun(t, work, "branch", "v5")
+
+	gitRun(t, r.root, "clone", "-q", "--bare", work, filepath.Join("actions", "checkout.git"))
+	return r
+}
+
+// serveHTTP serves root over smart HTTP with git http-backend.
+func (r *testRemote) serveHTTP(t *testing.T) string {
+	t.Helper()
+	backend := filepath.Join(gitRun(t, r.root, "--exec-path"), "git-http-backend")
+	if _, err := os.Stat(backend); err != nil {
+		t.Skip("git http-backend not installed")
+	}
+
+	srv := httptest.NewServer(&cgi.Handler{
+		Path:   backend,
+		Env:    []string{"GIT_PROJECT_ROOT=" + r.root, "GIT_HTTP_EXPORT_ALL=1", "GIT_CONFIG_GLOBAL=" + os.DevNull},
+		Stderr: io.Discard,
+	})
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func (r *testRemote) fileURL() string {
+	return "file://" + filepath.ToSlash(r.root)
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// remotes returns the base URLs actions/checkout is served at.
+func (r *testRemote) remotes(t *testing.T) map[string]string {
+	return map[string]string{"file": r.fileURL(), "http": r.serveHTTP(t)}
+}
+
+func TestListRefs(t *testing.T) {
+	r := newTestRemote(t)
+
+	for name, base := range r.remotes(t) {
+		t.Run(name, func(t *testing.T) {
+			refs, err := ListRefs(t.Context(), http.DefaultClient, base+"/actions/checkout.git", "refs/tags/v4")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]Ref)
+			for _, ref := range refs {
+				got[ref.Name] = ref
+			}
+			if len(got) != 2 {
+				t.Fatalf("ListRefs = %+v, want refs/tags/v4 and refs/tags/v4.1", refs)
+			}
+			if v4 := got["refs/tags/v4"]; v4.SHA != r.tagV4SHA || v4.Commit() != r.second {
+				t.Errorf("annotated tag v4 = %+v, want tag %s peeled to %s", v4, r.tagV4SHA, r.second)
+			}
+			if v41 := got["refs/tags/v4.1"]; v41.Peeled != "" || v41.Commit() != r.second {
+				t.Errorf("lightweight tag v4.1 = %+v, want %s unpeeled", v41, r.second)
+			}
+		})
+	}
+}
+
+func TestListRefsMissingRepository(t *testing.T) {
+	r := newTestRemote(t)
+
+	for name, base := range r.remotes(t) {
+		t.Run(name, func(t *testing.T) {
+			if refs, err := ListRefs(t.Context(), http.DefaultClient, base+"/actions/missing.git"); err == nil {
+				t.Errorf("ListRefs = %+v for a missing repository, want an error", refs)
+			}
+		})
+	}
+}
+
+// recordingResolver is a fallback that records what it was asked.
+type recordingResolver struct {
+	asked []string
+}
+
+func (f *recordingResolver) Resolve(ctx context.Context, owner, repo, ref string) github.ResolvedAction {
+	f.asked = append(f.asked, owner+"/"+repo+"@"+ref)
+	return github.ResolvedAction{SHA: "fallback", Version: ref}
+}
+
+func TestResolver(t *testing.T) {
+	r := newTestRemote(t)
+
+	for name, base := range r.remotes(t) {
+		t.Run(name, func(t *testing.T) {
+			tests := []struct {
+				repo, ref string
+				want      string
+				fallback  bool
+			}{
+				{"checkout", "v4", r.second, false},
+				{"checkout", "v3", r.first, false},
+				{"checkout", "v5", r.second, false},
+				// The tag wins over the branch of the same name.
+				{"checkout", "release", r.second, false},
+				// v4 must not match refs/tags/v4.1 and the like.
+				{"checkout", "v4.", "fallback", true},
+				{"checkout", r.first, "fallback", true},
+				{"missing", "v1", "fallback", true},
+			}
+
+			for _, tt := range tests {
+				fallback := &recordingResolver{}
+				got := NewResolver(base, fallback, nil).Resolve(t.Context(), "actions", tt.repo, tt.ref)
+				if got.Error != nil || got.SHA != tt.want {
+					t.Errorf("Resolve(%s@%s) = %+v, want %s", tt.repo, tt.ref, got, tt.want)
+				}
+				if asked := len(fallback.asked) > 0; asked != tt.fallback {
+					t.Errorf("Resolve(%s@%s) asked the fallback: %v, want %v", tt.repo, tt.ref, asked, tt.fallback)
+				}
+			}
+		})
+	}
+}
+
+func TestResolverWithoutFallback(t *testing.T) {
+	r := newTestRemote(t)
+
+	got := NewResolver(r.fileURL(), nil, nil).Resolve(t.Context(), "actions", "checkout", "v9")
+	var resolveErr *github.ResolveError
+	if !errors.As(got.Error, &resolveErr) || resolveErr.Reason != github.FailureNotFound {
+		t.Errorf("Resolve = %+v, want a not found error", got)
+	}
+}
+
+func pkt(line string) string {
+	var b bytes.Buffer
+	writePkt(&b, line)
+	return b.String()
+}
+
+func TestParseLsRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Ref
+		wantErr string
+	}{
+		{
+			name:  "peeled",
+			input: pkt("aaaa refs/tags/v1 peeled:bbbb symref-target:refs/heads/x\n") + pkt("cccc refs/heads/main") + "0000",
+			want:  []Ref{{Name: "refs/tags/v1", SHA: "aaaa", Peeled: "bbbb"}, {Name: "refs/heads/main", SHA: "cccc"}},
+		},
+		{name: "empty", input: "0000"},
+		{name: "remote error", input: pkt("ERR access denied") + "0000", wantErr: "remote error: access denied"},
+		{name: "malformed line", input: pkt("aaaa\n") + "0000", wantErr: "malformed ls-refs line"},
+		{name: "no flush", input: pkt("cccc refs/heads/main"), wantErr: "unexpected EOF"},
+		{name: "bad length", input: "zzzz", wantErr: "malformed pkt-line length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs, err := parseLsRefs(bufio.NewReader(strings.NewReader(tt.input)))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseLsRefs error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(refs) != len(tt.want) {
+				t.Fatalf("parseLsRefs = %+v, want %+v", refs, tt.want)
+			}
+			for i := range refs {
+				if refs[i] != tt.want[i] {
+					t.Errorf("ref %d = %+v, want %+v", i, refs[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
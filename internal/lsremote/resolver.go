@@ -0,0 +1,80 @@
+package lsremote
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+)
+
+// DefaultBaseURL is where GitHub serves repositories over git.
+const DefaultBaseURL = "https://github.com"
+
+// Resolver implements github.Resolver by listing refs over git. Tags win
+// over branches, as with the REST resolver. Anything it cannot answer,
+// including commit SHAs, missing repositories and transport failures, is
+// passed to Fallback, which reports the final outcome.
+type Resolver struct {
+	baseURL    string
+	httpClient *http.Client
+	fallback   github.Resolver
+}
+
+// NewResolver resolves owner/repo against baseURL/owner/repo.git, where
+// baseURL is an http(s) or file:// URL, and hands failures to fallback.
+func NewResolver(baseURL string, fallback github.Resolver, httpClient *http.Client) github.Resolver {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	return &Resolver{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+		fallback:   fallback,
+	}
+}
+
+// Unwrap returns the fallback resolver, so that the client still finds its
+// version listing, e.g. on Gitea, and can pass a new token on to it.
+func (r *Resolver) Unwrap() github.Resolver {
+	return r.fallback
+}
+
+func (r *Resolver) Resolve(ctx context.Context, owner, repo, ref string) github.ResolvedAction {
+	if sha, err := r.lookup(ctx, owner, repo, ref); err == nil && sha != "" {
+		return github.ResolvedAction{SHA: sha, Version: ref}
+	}
+	if r.fallback == nil {
+		return github.ResolvedAction{Error: &github.ResolveError{
+			Reason: github.FailureNotFound,
+			Err:    fmt.Errorf("no tag or branch named %q", ref),
+		}}
+	}
+	return r.fallback.Resolve(ctx, owner, repo, ref)
+}
+
+func (r *Resolver) lookup(ctx context.Context, owner, repo, ref string) (string, error) {
+	tag, branch := "refs/tags/"+ref, "refs/heads/"+ref
+	refs, err := ListRefs(ctx, r.httpClient, r.baseURL+"/"+owner+"/"+repo+".git", tag, branch)
+	if err != nil {
+		return "", err
+	}
+
+	// ref-prefix also matches refs/tags/v1.2 for v1.
+	var sha string
+	for _, found := range refs {
+		switch found.Name {
+		case tag:
+			return found.Commit(), nil
+		case branch:
+			sha = found.Commit()
+		}
+	}
+	return sha, nil
+}
@@ -0,0 +1,278 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/git"
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+const prTitle = "Pin GitHub Actions to commit SHAs"
+
+type Failure struct {
+	Action workflow.ActionReference
+	Reason string
+}
+
+type PinResult struct {
+	Repo         string
+	Base         string
+	Replacements []workflow.Replacement
+	Failures     []Failure
+	// PR is nil when every action was already pinned or none could be
+	// resolved.
+	PR *github.PullRequestResult
+}
+
+// Pinner pins the workflows of remote repositories and proposes the change
+// as a pull request. Resolutions are cached across repositories. A Pinner
+// is not safe for concurrent use.
+type Pinner struct {
+	client *github.Client
+	Branch string
+	OnWait func(wait time.Duration)
+	cache  map[string]github.ResolvedAction
+}
+
+func NewPinner(client *github.Client) *Pinner {
+	return &Pinner{
+		client: client,
+		Branch: github.DefaultPRBranch,
+		cache:  make(map[string]github.ResolvedAction),
+	}
+}
+
+// Pin reads the workflows of owner/name at base (the default branch if
+// empty) from the API, pins every resolvable action and opens or updates
+// the gha-freeze pull request.
+func (p *Pinner) Pin(ctx context.Context, owner, name, base string) (*PinResult, error) {
+	base, err := p.base(ctx, owner, name, base)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PinResult{Repo: owner + "/" + name, Base: base}
+
+	var files []github.WorkflowFile
+	err = retry(ctx, p.OnWait, func() error {
+		var err error
+		files, err = p.client.FetchWorkflows(ctx, owner, name, base, WorkflowDir)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []github.FileUpdate
+	for _, f := range files {
+		refs, err := workflow.ParseWorkflowContent(f.Content, f.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Path, err)
+		}
+
+		var repls []workflow.Replacement
+		for _, ref := range refs {
+			if ref.IsPinned {
+				continue
+			}
+
+			resolved, err := p.resolve(ctx, ref)
+			if err != nil {
+				return nil, err
+			}
+			if resolved.Error != nil {
+				result.Failures = append(result.Failures, Failure{
+					Action: ref,
+					Reason: github.ClassifyResolveError(resolved.Error),
+				})
+				continue
+			}
+			repls = append(repls, workflow.Replacement{Action: ref, SHA: resolved.SHA, Version: resolved.Version})
+		}
+
+		updated := workflow.ApplyReplacements(f.Content, repls)
+		if string(updated) != string(f.Content) {
+			updates = append(updates, github.FileUpdate{Path: f.Path, Content: updated})
+			result.Replacements = append(result.Replacements, repls...)
+		}
+	}
+
+	if err := p.open(ctx, owner, name, result, updates); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Propose opens or updates the gha-freeze pull request of owner/name with
+// changes already planned from a local checkout, so that it contains exactly
+// the replacements that run produced. Every changed file must still be the
+// same on base, or the pull request would revert whatever differs.
+func (p *Pinner) Propose(ctx context.Context, owner, name, base string, changes []workflow.FileChange, repls []workflow.Replacement, failures []Failure) (*PinResult, error) {
+	base, err := p.base(ctx, owner, name, base)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PinResult{Repo: owner + "/" + name, Base: base, Replacements: repls, Failures: failures}
+
+	updates := make([]github.FileUpdate, 0, len(changes))
+	for _, c := range changes {
+		path := filepath.ToSlash(c.Path)
+
+		var current []byte
+		err := retry(ctx, p.OnWait, func() error {
+			var err error
+			current, err = p.client.FetchFile(ctx, owner, name, base, path)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if current == nil || !bytes.Equal(current, c.Old) {
+			return nil, fmt.Errorf("%s differs between the checkout and %s on %s/%s: push or pull before opening a pull request", path, base, owner, name)
+		}
+
+		updates = append(updates, github.FileUpdate{Path: path, Content: c.New})
+	}
+
+	if err := p.open(ctx, owner, name, result, updates); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// base returns base, or the default branch of owner/name if it is empty.
+func (p *Pinner) base(ctx context.Context, owner, name, base string) (string, error) {
+	if base != "" {
+		return base, nil
+	}
+
+	var repo github.Repository
+	err := retry(ctx, p.OnWait, func() error {
+		var err error
+		repo, err = p.client.GetRepository(ctx, owner, name)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return repo.DefaultBranch, nil
+}
+
+// open commits updates and opens or updates the pull request described by
+// result. Without updates there is nothing to propose and result.PR stays
+// nil.
+func (p *Pinner) open(ctx context.Context, owner, name string, result *PinResult, updates []github.FileUpdate) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return retry(ctx, p.OnWait, func() error {
+		var err error
+		result.PR, err = p.client.OpenPullRequest(ctx, github.PullRequestOptions{
+			Owner:         owner,
+			Repo:          name,
+			Base:          result.Base,
+			Branch:        p.Branch,
+			Title:         prTitle,
+			Body:          PullRequestBody(p.client.WebURL(), result.Replacements, result.Failures),
+			CommitMessage: git.CommitMessage(result.Replacements),
+			Files:         updates,
+		})
+		return err
+	})
+}
+
+func (p *Pinner) resolve(ctx context.Context, ref workflow.ActionReference) (github.ResolvedAction, error) {
+	key := ref.Owner + "/" + ref.Repo + "@" + ref.Ref
+	if cached, ok := p.cache[key]; ok {
+		return cached, nil
+	}
+
+	var resolved github.ResolvedAction
+	err := retry(ctx, p.OnWait, func() error {
+		// github/codeql-action/init lives in github/codeql-action.
+		repo, _, _ := strings.Cut(ref.Repo, "/")
+		resolved = p.client.ResolveActionContext(ctx, ref.Owner, repo, ref.Ref)
+		if _, limited := github.RateLimitWait(resolved.Error); limited {
+			return resolved.Error
+		}
+		return nil
+	})
+	if err != nil {
+		return resolved, err
+	}
+
+	p.cache[key] = resolved
+	return resolved, nil
+}
+
+// PullRequestBody lists each pinned action with its version and a link to
+// the pinned commit, plus anything that had to be left alone.
+func PullRequestBody(webURL string, repls []workflow.Replacement, failures []Failure) string {
+	type row struct{ action, version, sha string }
+
+	seen := make(map[string]bool)
+	var rows []row
+	files := make(map[string]bool)
+	for _, r := range repls {
+		files[r.Action.FilePath] = true
+		action := r.Action.Owner + "/" + r.Action.Repo
+		key := action + "@" + r.SHA
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rows = append(rows, row{action: action, version: r.Version, sha: r.SHA})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].action != rows[j].action {
+			return rows[i].action < rows[j].action
+		}
+		return rows[i].version < rows[j].version
+	})
+
+	var b strings.Builder
+	b.WriteString("This pull request pins GitHub Actions to full commit SHAs so that workflow runs cannot change when a tag or branch is moved.\n\n")
+	b.WriteString("| Action | Version | Commit |\n|---|---|---|\n")
+	for _, r := range rows {
+		// Link to the action's repository, not a subdirectory such as
+		// github/codeql-action/init.
+		repo := strings.Join(strings.SplitN(r.action, "/", 3)[:2], "/")
+		fmt.Fprintf(&b, "| `%s` | %s | [`%s`](%s/%s/commit/%s) |\n", r.action, r.version, shortSHA(r.sha), webURL, repo, r.sha)
+	}
+
+	names := make([]string, 0, len(files))
+	for f := range files {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	b.WriteString("\nUpdated workflows:\n")
+	for _, f := range names {
+		fmt.Fprintf(&b, "- `%s`\n", f)
+	}
+
+	if len(failures) > 0 {
+		b.WriteString("\nCould not be resolved and were left unpinned:\n")
+		for _, f := range failures {
+			fmt.Fprintf(&b, "- `%s/%s@%s` in `%s`: %s\n", f.Action.Owner, f.Action.Repo, f.Action.Ref, f.Action.FilePath, f.Reason)
+		}
+	}
+
+	b.WriteString("\nGenerated by gha-freeze.\n")
+	return b.String()
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,122 @@
+package remote
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+const checkoutSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+
+// fakeRepo serves acme/app with one workflow on main and accepts the Git
+// Data and Pulls calls that open a pull request, recording uploaded blobs.
+type fakeRepo struct {
+	workflow string
+	blobs    []string
+}
+
+func (f *fakeRepo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3/repos/acme/app/")
+	reply := func(v any) { _ = json.NewEncoder(w).Encode(v) }
+
+	switch {
+	case path == "contents/"+WorkflowDir+"/ci.yml" && r.URL.Query().Get("ref") == "main":
+		reply(map[string]any{
+			"type":     "file",
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(f.workflow)),
+		})
+	case path == "git/ref/heads/main":
+		reply(map[string]any{"object": map[string]any{"sha": "base"}})
+	case path == "git/commits/base":
+		reply(map[string]any{"sha": "base", "tree": map[string]any{"sha": "tree"}})
+	case path == "git/blobs":
+		var blob struct{ Content string }
+		_ = json.NewDecoder(r.Body).Decode(&blob)
+		f.blobs = append(f.blobs, blob.Content)
+		reply(map[string]any{"sha": "blob"})
+	case path == "git/trees", path == "git/commits", path == "git/refs":
+		reply(map[string]any{"sha": "new", "object": map[string]any{"sha": "new"}})
+	case path == "pulls" && r.Method == http.MethodGet:
+		reply([]any{})
+	case path == "pulls":
+		reply(map[string]any{"number": 7, "html_url": "https://github.com/acme/app/pull/7"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// planLocal writes ci.yml into a checkout and pins only actions/checkout,
+// as if the other action had been deselected.
+func planLocal(t *testing.T) ([]workflow.FileChange, []workflow.Replacement) {
+	t.Helper()
+	root := t.TempDir()
+	path := filepath.Join(".github", "workflows", "ci.yml")
+	if err := os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, path), []byte(ciWorkflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := workflow.ParseWorkflowFile(root, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repls := []workflow.Replacement{{Action: refs[0], SHA: checkoutSHA, Version: "v4.1.7"}}
+	changes, err := workflow.PlanChanges(root, repls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return changes, repls
+}
+
+func TestPropose(t *testing.T) {
+	f := &fakeRepo{workflow: ciWorkflow}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	changes, repls := planLocal(t)
+	pinner := NewPinner(github.NewClient("", github.WithBaseURL(srv.URL+"/api/v3/")))
+	result, err := pinner.Propose(t.Context(), "acme", "app", "main", changes, repls, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.PR == nil || result.PR.Number != 7 {
+		t.Fatalf("PR = %+v, want #7", result.PR)
+	}
+	if len(f.blobs) != 1 || f.blobs[0] != string(changes[0].New) {
+		t.Fatalf("uploaded %q, want the locally planned content", f.blobs)
+	}
+	if !strings.Contains(f.blobs[0], "actions/checkout@"+checkoutSHA+" # v4.1.7") {
+		t.Errorf("checkout not pinned to the chosen version:\n%s", f.blobs[0])
+	}
+	if !strings.Contains(f.blobs[0], "actions/setup-go@0c52d547c9bc32b1aa3301fd7a9cb496313a4491") {
+		t.Errorf("untouched action changed:\n%s", f.blobs[0])
+	}
+}
+
+func TestProposeRejectsDivergedBase(t *testing.T) {
+	f := &fakeRepo{workflow: strings.Replace(ciWorkflow, "on: push", "on: [push, pull_request]", 1)}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	changes, repls := planLocal(t)
+	pinner := NewPinner(github.NewClient("", github.WithBaseURL(srv.URL+"/api/v3/")))
+	_, err := pinner.Propose(t.Context(), "acme", "app", "main", changes, repls, nil)
+	if err == nil || !strings.Contains(err.Error(), "differs") {
+		t.Fatalf("Propose error = %v, want the file to differ from main", err)
+	}
+	if len(f.blobs) != 0 {
+		t.Errorf("uploaded %d blobs despite the error", len(f.blobs))
+	}
+}
@@ -0,0 +1,118 @@
+package remote
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON, FormatCSV:
+		return Format(s), nil
+	}
+	return "", fmt.Errorf("invalid format %q: expected text, json or csv", s)
+}
+
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	}
+	return r.WriteText(w)
+}
+
+// ReposWithUnpinned counts repositories that use at least one unpinned
+// action.
+func (r *Report) ReposWithUnpinned() int {
+	var n int
+	for _, repo := range r.Repos {
+		if repo.Unpinned > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "REPO\tBRANCH\tWORKFLOWS\tACTIONS\tUNPINNED\tERROR\n")
+	var unpinned int
+	for _, repo := range r.Repos {
+		errMsg := "-"
+		if len(repo.Errors) > 0 {
+			errMsg = strings.Join(repo.Errors, "; ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n", repo.Repo, repo.Branch, repo.Workflows, repo.Actions, repo.Unpinned, errMsg)
+		unpinned += repo.Unpinned
+	}
+
+	fmt.Fprintf(tw, "\nACTION\tREF\tPINNED\tUSES\tREPOS\n")
+	for _, a := range r.Actions {
+		pinned := "no"
+		if a.Pinned {
+			pinned = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%d\n", a.Action, a.Ref, pinned, a.Uses, len(a.Repos))
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d of %d repositories in %s use unpinned actions (%d unpinned references)\n",
+		r.ReposWithUnpinned(), len(r.Repos), r.Org, unpinned)
+	return err
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteCSV writes one row per action reference, which aggregates easily in
+// a spreadsheet. Each scan error of a repository gets a row of its own.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"repo", "branch", "workflow", "line", "action", "ref", "pinned", "error"}); err != nil {
+		return err
+	}
+
+	for _, repo := range r.Repos {
+		for _, e := range repo.Errors {
+			if err := cw.Write([]string{repo.Repo, repo.Branch, "", "", "", "", "", e}); err != nil {
+				return err
+			}
+		}
+		for _, ref := range repo.References {
+			row := []string{
+				repo.Repo, repo.Branch, ref.Workflow, strconv.Itoa(ref.Line),
+				ref.Action, ref.Ref, strconv.FormatBool(ref.Pinned), "",
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
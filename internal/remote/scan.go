@@ -0,0 +1,275 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+const (
+	WorkflowDir        = ".github/workflows"
+	DefaultConcurrency = 4
+	// maxRateLimitWait caps a single wait so a bad reset time cannot
+	// stall a scan for hours.
+	maxRateLimitWait = time.Hour
+)
+
+type Options struct {
+	// Include and Exclude are path.Match patterns on the repository name.
+	// With Include set, only matching repositories are scanned.
+	Include         []string
+	Exclude         []string
+	IncludeArchived bool
+	IncludeForks    bool
+	Concurrency     int
+	// OnWait is called before sleeping on a rate limit.
+	OnWait func(wait time.Duration)
+	// OnRepo is called after each repository has been scanned.
+	OnRepo func(done, total int, repo RepoReport)
+}
+
+// RepoReport is the scan result of one repository. Errors holds the reason
+// its workflows could not be fetched, or one entry per workflow that failed
+// to parse; the remaining workflows are still counted.
+type RepoReport struct {
+	Repo       string      `json:"repo"`
+	Branch     string      `json:"branch"`
+	Workflows  int         `json:"workflows"`
+	Actions    int         `json:"actions"`
+	Unpinned   int         `json:"unpinned"`
+	References []Reference `json:"references"`
+	Errors     []string    `json:"errors,omitempty"`
+}
+
+type Reference struct {
+	Workflow string `json:"workflow"`
+	Line     int    `json:"line"`
+	Action   string `json:"action"`
+	Ref      string `json:"ref"`
+	Pinned   bool   `json:"pinned"`
+}
+
+type ActionUsage struct {
+	Action string   `json:"action"`
+	Ref    string   `json:"ref"`
+	Pinned bool     `json:"pinned"`
+	Uses   int      `json:"uses"`
+	Repos  []string `json:"repos"`
+}
+
+type Report struct {
+	Org     string        `json:"org"`
+	Scanned time.Time     `json:"scanned_at"`
+	Repos   []RepoReport  `json:"repos"`
+	Actions []ActionUsage `json:"actions"`
+}
+
+// ScanOrg fetches the workflows of every matching repository in org at its
+// default branch and parses them without cloning anything.
+func ScanOrg(ctx context.Context, client *github.Client, org string, opts Options) (*Report, error) {
+	if err := validatePatterns(opts.Include, opts.Exclude); err != nil {
+		return nil, err
+	}
+
+	var all []github.Repository
+	err := retry(ctx, opts.OnWait, func() error {
+		var err error
+		all, err = client.ListOrgRepos(ctx, org)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []github.Repository
+	for _, r := range all {
+		if (r.Archived && !opts.IncludeArchived) || (r.Fork && !opts.IncludeForks) {
+			continue
+		}
+		if matchRepo(r.Name, opts.Include, opts.Exclude) {
+			repos = append(repos, r)
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Name < repos[j].Name })
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
+
+	reports := make([]RepoReport, len(repos))
+	work := make(chan int)
+	var mu sync.Mutex
+	var done int
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(repos); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				reports[i] = scanRepo(ctx, client, repos[i], opts)
+				if opts.OnRepo != nil {
+					mu.Lock()
+					done++
+					opts.OnRepo(done, len(repos), reports[i])
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for i := range repos {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &Report{
+		Org:     org,
+		Scanned: time.Now().UTC(),
+		Repos:   reports,
+		Actions: aggregate(reports),
+	}, nil
+}
+
+func scanRepo(ctx context.Context, client *github.Client, repo github.Repository, opts Options) RepoReport {
+	report := RepoReport{Repo: repo.FullName(), Branch: repo.DefaultBranch, References: []Reference{}}
+
+	var files []github.WorkflowFile
+	err := retry(ctx, opts.OnWait, func() error {
+		var err error
+		files, err = client.FetchWorkflows(ctx, repo.Owner, repo.Name, repo.DefaultBranch, WorkflowDir)
+		return err
+	})
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		return report
+	}
+
+	report.Workflows = len(files)
+	for _, f := range files {
+		refs, err := workflow.ParseWorkflowContent(f.Content, f.Path)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", f.Path, err))
+			continue
+		}
+		for _, ref := range refs {
+			report.Actions++
+			if !ref.IsPinned {
+				report.Unpinned++
+			}
+			report.References = append(report.References, Reference{
+				Workflow: ref.FilePath,
+				Line:     ref.Line,
+				Action:   ref.Owner + "/" + ref.Repo,
+				Ref:      ref.Ref,
+				Pinned:   ref.IsPinned,
+			})
+		}
+	}
+
+	return report
+}
+
+// retry runs fn again after waiting out rate limit errors.
+func retry(ctx context.Context, onWait func(time.Duration), fn func() error) error {
+	for {
+		err := fn()
+		wait, limited := github.RateLimitWait(err)
+		if err == nil || !limited {
+			return err
+		}
+
+		if wait > maxRateLimitWait {
+			wait = maxRateLimitWait
+		}
+		if onWait != nil {
+			onWait(wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func aggregate(reports []RepoReport) []ActionUsage {
+	byAction := make(map[string]*ActionUsage)
+	for _, r := range reports {
+		for _, ref := range r.References {
+			key := ref.Action + "@" + ref.Ref
+			usage, ok := byAction[key]
+			if !ok {
+				usage = &ActionUsage{Action: ref.Action, Ref: ref.Ref, Pinned: ref.Pinned}
+				byAction[key] = usage
+			}
+			usage.Uses++
+			if n := len(usage.Repos); n == 0 || usage.Repos[n-1] != r.Repo {
+				usage.Repos = append(usage.Repos, r.Repo)
+			}
+		}
+	}
+
+	actions := make([]ActionUsage, 0, len(byAction))
+	for _, u := range byAction {
+		actions = append(actions, *u)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].Pinned != actions[j].Pinned {
+			return !actions[i].Pinned
+		}
+		if len(actions[i].Repos) != len(actions[j].Repos) {
+			return len(actions[i].Repos) > len(actions[j].Repos)
+		}
+		if actions[i].Action != actions[j].Action {
+			return actions[i].Action < actions[j].Action
+		}
+		return actions[i].Ref < actions[j].Ref
+	})
+	return actions
+}
+
+func validatePatterns(patterns ...[]string) error {
+	for _, list := range patterns {
+		for _, p := range list {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid repository pattern %q: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+func matchRepo(name string, include, exclude []string) bool {
+	for _, p := range exclude {
+		if ok, _ := path.Match(p, name); ok {
+			return false
+		}
+	}
+	if len(include) == 0 {
+		return true
+	}
+	for _, p := range include {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
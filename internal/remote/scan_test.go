@@ -0,0 +1,247 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+)
+
+const ciWorkflow = `on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@0c52d547c9bc32b1aa3301fd7a9cb496313a4491
+`
+
+// fakeOrg serves the parts of the GitHub API a scan uses for the acme
+// organization: a paginated repository list and the contents API.
+type fakeOrg struct {
+	// files maps repository name to workflow file name to content.
+	files map[string]map[string]string
+	// limited makes the first contents request of a repository fail with
+	// a secondary rate limit.
+	limited map[string]*atomic.Bool
+}
+
+func (f *fakeOrg) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const prefix = "/api/v3/repos/acme/"
+	switch {
+	case r.URL.Path == "/api/v3/orgs/acme/repos":
+		f.serveRepos(w, r)
+	case strings.HasPrefix(r.URL.Path, prefix):
+		name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, prefix), "/")
+		if l := f.limited[name]; l != nil && !l.Swap(true) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message":"You have exceeded a secondary rate limit","documentation_url":"https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits"}`)
+			return
+		}
+		f.serveContents(w, name, strings.TrimPrefix(rest, "contents/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeOrg) serveRepos(w http.ResponseWriter, r *http.Request) {
+	repo := func(name, branch string, archived, fork bool) map[string]any {
+		return map[string]any{
+			"name":           name,
+			"owner":          map[string]any{"login": "acme"},
+			"default_branch": branch,
+			"archived":       archived,
+			"fork":           fork,
+		}
+	}
+
+	var page []map[string]any
+	if r.URL.Query().Get("page") == "2" {
+		page = append(page, repo("lib", "trunk", false, false), repo("sandbox-old", "main", false, false))
+	} else {
+		w.Header().Set("Link", fmt.Sprintf(`<http://%s/api/v3/orgs/acme/repos?page=2>; rel="next"`, r.Host))
+		page = append(page, repo("app", "main", false, false), repo("legacy", "main", true, false), repo("upstream", "main", false, true))
+	}
+	_ = json.NewEncoder(w).Encode(page)
+}
+
+func (f *fakeOrg) serveContents(w http.ResponseWriter, repo, path string) {
+	files, ok := f.files[repo]
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+
+	if path == WorkflowDir {
+		var entries []map[string]any
+		for name := range files {
+			entries = append(entries, map[string]any{"type": "file", "name": name, "path": WorkflowDir + "/" + name})
+		}
+		entries = append(entries, map[string]any{"type": "dir", "name": "templates", "path": WorkflowDir + "/templates"})
+		_ = json.NewEncoder(w).Encode(entries)
+		return
+	}
+
+	name := strings.TrimPrefix(path, WorkflowDir+"/")
+	content, ok := files[name]
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"type":     "file",
+		"name":     name,
+		"path":     path,
+		"encoding": "base64",
+		"content":  base64.StdEncoding.EncodeToString([]byte(content)),
+	})
+}
+
+func scanFakeOrg(t *testing.T, opts Options) *Report {
+	t.Helper()
+	f := &fakeOrg{
+		files: map[string]map[string]string{
+			"app": {
+				"ci.yml":      ciWorkflow,
+				"broken.yml":  "jobs: [\n",
+				"scalar.yaml": "just a string\n",
+				"README.md":   "not a workflow\n",
+			},
+			"lib": {"ci.yml": ciWorkflow},
+		},
+		limited: map[string]*atomic.Bool{"lib": new(atomic.Bool)},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	client := github.NewClient("", github.WithBaseURL(srv.URL+"/api/v3/"))
+	report, err := ScanOrg(t.Context(), client, "acme", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func repoNames(report *Report) []string {
+	var names []string
+	for _, r := range report.Repos {
+		names = append(names, r.Repo)
+	}
+	return names
+}
+
+func TestScanOrg(t *testing.T) {
+	var waits int
+	report := scanFakeOrg(t, Options{
+		Exclude: []string{"sandbox-*"},
+		OnWait:  func(time.Duration) { waits++ },
+	})
+
+	if got := strings.Join(repoNames(report), ","); got != "acme/app,acme/lib" {
+		t.Fatalf("scanned %s, want acme/app,acme/lib", got)
+	}
+	if waits != 1 {
+		t.Errorf("waited %d times on the rate limit, want 1", waits)
+	}
+
+	app, lib := report.Repos[0], report.Repos[1]
+	if app.Workflows != 3 || app.Actions != 2 || app.Unpinned != 1 {
+		t.Errorf("app = %d workflows, %d actions, %d unpinned; want 3, 2, 1", app.Workflows, app.Actions, app.Unpinned)
+	}
+	if len(app.Errors) != 2 ||
+		!strings.HasPrefix(app.Errors[0], WorkflowDir+"/") || !strings.HasPrefix(app.Errors[1], WorkflowDir+"/") ||
+		app.Errors[0] == app.Errors[1] {
+		t.Errorf("app errors = %q, want one per broken workflow", app.Errors)
+	}
+	if lib.Branch != "trunk" || lib.Unpinned != 1 || len(lib.Errors) != 0 {
+		t.Errorf("lib = %+v, want scanned at trunk after the rate limit", lib)
+	}
+
+	if len(report.Actions) != 2 || report.Actions[0].Action != "actions/checkout" || len(report.Actions[0].Repos) != 2 {
+		t.Errorf("actions = %+v, want actions/checkout first, used by both repositories", report.Actions)
+	}
+}
+
+func TestScanOrgFilters(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"default", Options{}, "acme/app,acme/lib,acme/sandbox-old"},
+		{"include", Options{Include: []string{"l*"}}, "acme/lib"},
+		{"exclude", Options{Exclude: []string{"app", "sandbox-*"}}, "acme/lib"},
+		{"archived", Options{IncludeArchived: true, Include: []string{"legacy"}}, "acme/legacy"},
+		{"forks", Options{IncludeForks: true, Include: []string{"upstream"}}, "acme/upstream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := scanFakeOrg(t, tt.opts)
+			if got := strings.Join(repoNames(report), ","); got != tt.want {
+				t.Errorf("scanned %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanOrgInvalidPattern(t *testing.T) {
+	client := github.NewClient("", github.WithBaseURL("http://127.0.0.1:0/"))
+	if _, err := ScanOrg(t.Context(), client, "acme", Options{Include: []string{"["}}); err == nil {
+		t.Error("ScanOrg accepted an invalid pattern")
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+	report := scanFakeOrg(t, Options{Include: []string{"app"}})
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "; ") || !strings.Contains(text.String(), "1 of 1 repositories in acme") {
+		t.Errorf("text report does not list both errors:\n%s", text.String())
+	}
+
+	var js bytes.Buffer
+	if err := report.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Repos) != 1 || len(decoded.Repos[0].Errors) != 2 {
+		t.Errorf("JSON report = %s", js.String())
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errorRows, refRows int
+	for _, row := range rows[1:] {
+		if row[7] != "" {
+			errorRows++
+		} else {
+			refRows++
+		}
+	}
+	if errorRows != 2 || refRows != 2 {
+		t.Errorf("CSV has %d error and %d reference rows, want 2 and 2:\n%v", errorRows, refRows, rows)
+	}
+}
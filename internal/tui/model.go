@@ -2,13 +2,17 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/thinesjs/gha-freeze/internal/backup"
 	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/remote"
 	"github.com/thinesjs/gha-freeze/internal/workflow"
 )
 
@@ -27,27 +31,55 @@ const (
 	StateRestoring
 	StateError
 	StateRateLimited
+	StateVersionPicker
 )
 
 type Model struct {
-	state         State
-	spinner       spinner.Model
-	workflowFiles []string
-	selectedFiles []string
-	fileList      list.Model
-	backupList    list.Model
-	actions       []workflow.ActionReference
-	replacements  []workflow.Replacement
-	err           error
-	githubClient  *github.Client
-	backupPath    string
-	totalCount    int
-	dryRun        bool
-	noBackup      bool
-	message       string
-	tokenPrompt   bool
-	tokenInput    string
-	version       string
+	state          State
+	spinner        spinner.Model
+	workflowFiles  []string
+	selectedFiles  []string
+	fileList       list.Model
+	backupList     list.Model
+	actions        []workflow.ActionReference
+	actionSelected []bool
+	actionCursor   int
+	actionFilter   textinput.Model
+	filtering      bool
+	pending        []workflow.ActionReference
+	pendingRetry   bool
+	partialRepls   []workflow.Replacement
+	partialFails   []ResolveFailure
+	refOverrides   map[string]string
+	versionList    list.Model
+	versionTarget  int
+	versionErr     error
+	replacements   []workflow.Replacement
+	failures       []ResolveFailure
+	changes        []workflow.FileChange
+	diffView       viewport.Model
+	err            error
+	githubClient   *github.Client
+	backupPath     string
+	totalCount     int
+	dryRun         bool
+	noBackup       bool
+	backupLoc      backup.Location
+	gitBranch      string
+	gitCommit      bool
+	force          bool
+	commitSHA      string
+	message        string
+	tokenPrompt    bool
+	tokenInput     textinput.Model
+	validating     bool
+	tokenInfo      *github.TokenInfo
+	tokenErr       error
+	version        string
+	workflowDirs   []string
+	root           string
+	pullRequest    *PullRequest
+	prResult       *github.PullRequestResult
 }
 
 type workflowFileItem struct {
@@ -77,15 +109,37 @@ type scanCompleteMsg struct {
 
 type resolveCompleteMsg struct {
 	replacements []workflow.Replacement
+	failures     []ResolveFailure
+	retry        bool
 	err          error
 }
 
+type ResolveFailure struct {
+	Action workflow.ActionReference
+	Reason string
+	Err    error
+}
+
 type processCompleteMsg struct {
 	backupPath string
+	commitSHA  string
+	pr         *github.PullRequestResult
 	err        error
 }
 
-type rateLimitMsg struct{}
+// rateLimitMsg carries what was resolved before the rate limit was hit, so
+// that resolving can resume with remaining once a token is provided.
+type rateLimitMsg struct {
+	replacements []workflow.Replacement
+	failures     []ResolveFailure
+	remaining    []workflow.ActionReference
+	retry        bool
+}
+
+type tokenValidatedMsg struct {
+	info *github.TokenInfo
+	err  error
+}
 
 type backupListMsg struct {
 	backups []backup.BackupInfo
@@ -96,6 +150,41 @@ type restoreCompleteMsg struct {
 	err error
 }
 
+type versionsMsg struct {
+	index    int
+	versions []github.Version
+	err      error
+}
+
+type versionItem struct {
+	version github.Version
+	current bool
+}
+
+func (i versionItem) Title() string {
+	title := i.version.Tag
+	if i.current {
+		title += " (current)"
+	}
+	return title
+}
+
+func (i versionItem) Description() string {
+	var parts []string
+	if !i.version.PublishedAt.IsZero() {
+		parts = append(parts, "published "+i.version.PublishedAt.Format("2006-01-02"))
+	}
+	if i.version.Latest {
+		parts = append(parts, "latest")
+	}
+	if i.version.Prerelease {
+		parts = append(parts, "prerelease")
+	}
+	return strings.Join(parts, " • ")
+}
+
+func (i versionItem) FilterValue() string { return i.version.Tag }
+
 type backupItem struct {
 	info backup.BackupInfo
 }
@@ -106,28 +195,73 @@ func (i backupItem) Title() string {
 func (i backupItem) Description() string { return i.info.Path }
 func (i backupItem) FilterValue() string { return i.info.Timestamp }
 
-func NewModel(token string, dryRun, noBackup bool, version string) Model {
+type Options struct {
+	DryRun         bool
+	NoBackup       bool
+	BackupLocation backup.Location
+	GitBranch      string
+	Commit         bool
+	Force          bool
+	Version        string
+	WorkflowDirs   []string
+	Root           string // repository root; workflow paths are relative to it
+	PullRequest    *PullRequest
+}
+
+// PullRequest proposes the pinned workflows as a pull request on Owner/Repo
+// against Base (the default branch if empty) instead of writing them to the
+// working tree.
+type PullRequest struct {
+	Pinner *remote.Pinner
+	Owner  string
+	Repo   string
+	Base   string
+}
+
+func NewModel(client *github.Client, opts Options) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 
+	ti := textinput.New()
+	ti.Placeholder = "ghp_..."
+	ti.EchoMode = textinput.EchoPassword
+	ti.EchoCharacter = '*'
+	ti.Width = 60
+
 	return Model{
 		state:        StateLoading,
 		spinner:      s,
-		githubClient: github.NewClient(token),
-		dryRun:       dryRun,
-		noBackup:     noBackup,
-		version:      version,
+		tokenInput:   ti,
+		actionFilter: newFilterInput(),
+		refOverrides: make(map[string]string),
+		githubClient: client,
+		dryRun:       opts.DryRun,
+		noBackup:     opts.NoBackup,
+		backupLoc:    opts.BackupLocation,
+		gitBranch:    opts.GitBranch,
+		gitCommit:    opts.Commit || opts.GitBranch != "",
+		force:        opts.Force,
+		version:      opts.Version,
+		workflowDirs: opts.WorkflowDirs,
+		root:         opts.Root,
+		pullRequest:  opts.PullRequest,
 	}
 }
 
+func (m Model) Failures() []ResolveFailure {
+	return m.failures
+}
+
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		m.spinner.Tick,
-		loadWorkflowFiles,
+		loadWorkflowFiles(m.root, m.workflowDirs),
 	)
 }
 
-func loadWorkflowFiles() tea.Msg {
-	files, err := workflow.FindWorkflowFiles()
-	return loadingCompleteMsg{files: files, err: err}
+func loadWorkflowFiles(root string, dirs []string) tea.Cmd {
+	return func() tea.Msg {
+		files, err := workflow.FindWorkflowFilesIn(root, dirs)
+		return loadingCompleteMsg{files: files, err: err}
+	}
 }
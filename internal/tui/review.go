@@ -0,0 +1,244 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+const reviewPageSize = 15
+
+func newFilterInput() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "/"
+	ti.Placeholder = "filter by owner, repo, ref or file"
+	ti.Width = 40
+	return ti
+}
+
+func (m *Model) setReviewActions(actions []workflow.ActionReference) {
+	sort.SliceStable(actions, func(i, j int) bool {
+		if actions[i].FilePath != actions[j].FilePath {
+			return actions[i].FilePath < actions[j].FilePath
+		}
+		return actions[i].Line < actions[j].Line
+	})
+
+	m.actions = actions
+	m.actionSelected = make([]bool, len(actions))
+	for i := range m.actionSelected {
+		m.actionSelected[i] = true
+	}
+	m.actionCursor = 0
+	m.actionFilter.SetValue("")
+}
+
+func (m Model) visibleActions() []int {
+	filter := strings.ToLower(strings.TrimSpace(m.actionFilter.Value()))
+
+	var visible []int
+	for i, a := range m.actions {
+		haystack := strings.ToLower(fmt.Sprintf("%s/%s@%s %s", a.Owner, a.Repo, a.Ref, a.FilePath))
+		if filter == "" || strings.Contains(haystack, filter) {
+			visible = append(visible, i)
+		}
+	}
+	return visible
+}
+
+func (m Model) selectedActions() []workflow.ActionReference {
+	var selected []workflow.ActionReference
+	for i, a := range m.actions {
+		if m.actionSelected[i] {
+			selected = append(selected, a)
+		}
+	}
+	return selected
+}
+
+func (m Model) handleReviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.filtering {
+		switch msg.String() {
+		case "enter":
+			m.filtering = false
+			m.actionFilter.Blur()
+			return m, nil
+		case "esc":
+			m.filtering = false
+			m.actionFilter.Blur()
+			m.actionFilter.SetValue("")
+			m.actionCursor = 0
+			return m, nil
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+		var cmd tea.Cmd
+		m.actionFilter, cmd = m.actionFilter.Update(msg)
+		m.actionCursor = 0
+		return m, cmd
+	}
+
+	visible := m.visibleActions()
+
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+	case "enter":
+		return m.handleEnterKey()
+	case "up", "k":
+		if m.actionCursor > 0 {
+			m.actionCursor--
+		}
+	case "down", "j":
+		if m.actionCursor < len(visible)-1 {
+			m.actionCursor++
+		}
+	case " ":
+		if m.actionCursor < len(visible) {
+			idx := visible[m.actionCursor]
+			m.actionSelected[idx] = !m.actionSelected[idx]
+		}
+	case "a":
+		m.setSelection(visible, !m.allSelected(visible))
+	case "o":
+		if m.actionCursor < len(visible) {
+			owner := m.actions[visible[m.actionCursor]].Owner
+			var same []int
+			for i, a := range m.actions {
+				if a.Owner == owner {
+					same = append(same, i)
+				}
+			}
+			m.setSelection(same, !m.allSelected(same))
+		}
+	case "v":
+		if m.actionCursor < len(visible) {
+			return m.openVersionPicker(visible[m.actionCursor])
+		}
+	case "/":
+		m.filtering = true
+		m.actionFilter.Focus()
+		return m, textinput.Blink
+	case "esc":
+		m.actionFilter.SetValue("")
+		m.actionCursor = 0
+	}
+
+	return m, nil
+}
+
+func (m Model) allSelected(indices []int) bool {
+	for _, i := range indices {
+		if !m.actionSelected[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Model) setSelection(indices []int, selected bool) {
+	for _, i := range indices {
+		m.actionSelected[i] = selected
+	}
+}
+
+// actionKey identifies one occurrence of an action. Lines come from the
+// YAML tree, so repeated steps using the same action get distinct keys.
+func actionKey(a workflow.ActionReference) string {
+	return fmt.Sprintf("%s:%d:%s", a.FilePath, a.Line, a.FullUses)
+}
+
+func (m Model) targetRef(a workflow.ActionReference) string {
+	if ref, ok := m.refOverrides[actionKey(a)]; ok {
+		return ref
+	}
+	return a.Ref
+}
+
+func (m Model) openVersionPicker(index int) (tea.Model, tea.Cmd) {
+	m.state = StateVersionPicker
+	m.versionTarget = index
+	m.versionErr = nil
+	m.versionList = list.New(nil, list.NewDefaultDelegate(), 80, 0)
+
+	action := m.actions[index]
+	client := m.githubClient
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		versions, err := client.ListVersions(action.Owner, action.Repo)
+		return versionsMsg{index: index, versions: versions, err: err}
+	})
+}
+
+func (m Model) handleVersions(msg versionsMsg) (tea.Model, tea.Cmd) {
+	if m.state != StateVersionPicker || msg.index != m.versionTarget {
+		return m, nil
+	}
+
+	if msg.err != nil {
+		m.versionErr = msg.err
+		return m, nil
+	}
+
+	if len(msg.versions) == 0 {
+		m.versionErr = fmt.Errorf("no tags or releases found")
+		return m, nil
+	}
+
+	current := m.targetRef(m.actions[m.versionTarget])
+	items := make([]list.Item, len(msg.versions))
+	selected := 0
+	for i, v := range msg.versions {
+		items[i] = versionItem{version: v, current: v.Tag == current}
+		if v.Tag == current {
+			selected = i
+		}
+	}
+
+	delegate := list.NewDefaultDelegate()
+	delegate.SetSpacing(0)
+
+	m.versionList = list.New(items, delegate, 80, min(len(items)*2+2, 20))
+	m.versionList.SetShowTitle(false)
+	m.versionList.SetShowStatusBar(false)
+	m.versionList.SetShowHelp(false)
+	m.versionList.Select(selected)
+	return m, nil
+}
+
+func (m Model) handleVersionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.versionList.FilterState() == list.Filtering {
+		var cmd tea.Cmd
+		m.versionList, cmd = m.versionList.Update(msg)
+		return m, cmd
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.state = StateActionReview
+		return m, nil
+	case "enter":
+		if item, ok := m.versionList.SelectedItem().(versionItem); ok {
+			action := m.actions[m.versionTarget]
+			if item.version.Tag == action.Ref {
+				delete(m.refOverrides, actionKey(action))
+			} else {
+				m.refOverrides[actionKey(action)] = item.version.Tag
+			}
+			m.actionSelected[m.versionTarget] = true
+		}
+		m.state = StateActionReview
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.versionList, cmd = m.versionList.Update(msg)
+	return m, cmd
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+// pickVersion drives the version picker for the action at index.
+func pickVersion(t *testing.T, m Model, index int, tag string) Model {
+	t.Helper()
+	m.state = StateVersionPicker
+	m.versionTarget = index
+
+	versions := []github.Version{{Tag: "v4"}, {Tag: "v3"}, {Tag: "v2"}}
+	updated, _ := m.handleVersions(versionsMsg{index: index, versions: versions})
+	m = updated.(Model)
+	for i, v := range versions {
+		if v.Tag == tag {
+			m.versionList.Select(i)
+		}
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	return updated.(Model)
+}
+
+func TestDuplicateStepsGetTheirOwnRefs(t *testing.T) {
+	const content = `on: push
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/checkout@v4
+      - uses: actions/checkout@v4
+`
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "ci.yml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	actions, err := workflow.ParseWorkflowFile(root, "ci.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewModel(github.NewClient("", github.WithResolver(newFakeResolver())), Options{})
+	m.setReviewActions(actions)
+	m.state = StateActionReview
+
+	m = pickVersion(t, m, 0, "v3")
+	m = pickVersion(t, m, 1, "v2")
+	if got := m.targetRef(m.actions[0]); got != "v3" {
+		t.Errorf("first step targets %s, want v3", got)
+	}
+	if got := m.targetRef(m.actions[1]); got != "v2" {
+		t.Errorf("second step targets %s, want v2", got)
+	}
+
+	// Deselect the third occurrence; it must stay as it is.
+	m.actionSelected[2] = false
+	msg := m.resolveActions(m.selectedActions(), false)().(resolveCompleteMsg)
+	changes, err := workflow.PlanChanges(root, msg.replacements)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("got %d changed files, want 1", len(changes))
+	}
+
+	lines := strings.Split(string(changes[0].New), "\n")
+	want := []string{
+		"      - uses: actions/checkout@sha-checkout-v3 # v3",
+		"      - uses: actions/checkout@sha-checkout-v2 # v2",
+		"      - uses: actions/checkout@v4",
+	}
+	for i, w := range want {
+		if lines[4+i] != w {
+			t.Errorf("line %d = %q, want %q", 5+i, lines[4+i], w)
+		}
+	}
+}
@@ -1,14 +1,21 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/thinesjs/gha-freeze/internal/backup"
+	"github.com/thinesjs/gha-freeze/internal/config"
+	"github.com/thinesjs/gha-freeze/internal/git"
 	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/remote"
 	"github.com/thinesjs/gha-freeze/internal/workflow"
 )
 
@@ -29,6 +36,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, cmd
 			}
 		}
+		if m.state == StateRateLimited {
+			return m.handleTokenKey(msg)
+		}
+		if m.state == StateActionReview {
+			return m.handleReviewKey(msg)
+		}
+		if m.state == StateVersionPicker {
+			return m.handleVersionKey(msg)
+		}
 		return m.handleKeyPress(msg)
 
 	case loadingCompleteMsg:
@@ -44,7 +60,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleProcessComplete(msg)
 
 	case rateLimitMsg:
-		return m.handleRateLimit()
+		return m.handleRateLimit(msg)
+
+	case tokenValidatedMsg:
+		return m.handleTokenValidated(msg)
+
+	case versionsMsg:
+		return m.handleVersions(msg)
 
 	case backupListMsg:
 		return m.handleBackupList(msg)
@@ -64,6 +86,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	if m.state == StateRateLimited && m.tokenPrompt {
+		var cmd tea.Cmd
+		m.tokenInput, cmd = m.tokenInput.Update(msg)
+		return m, cmd
+	}
+
+	if m.state == StateActionReview && m.filtering {
+		var cmd tea.Cmd
+		m.actionFilter, cmd = m.actionFilter.Update(msg)
+		return m, cmd
+	}
+
 	return m, nil
 }
 
@@ -96,6 +130,15 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.state == StateConfirming && m.dryRun && len(m.changes) > 0 {
+		switch msg.String() {
+		case "up", "down", "k", "j", "pgup", "pgdown", "home", "end":
+			var cmd tea.Cmd
+			m.diffView, cmd = m.diffView.Update(msg)
+			return m, cmd
+		}
+	}
+
 	if m.state == StateError {
 		if msg.String() == "q" || msg.String() == "ctrl+c" {
 			return m, tea.Quit
@@ -112,30 +155,114 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "enter":
 		return m.handleEnterKey()
 
+	case "t":
+		if m.state == StateConfirming && len(m.failures) > 0 {
+			return m.retryFailures()
+		}
+
 	case " ":
 		if m.state == StateFileSelection {
 			return m.toggleFileSelection()
 		}
 	}
 
-	if m.state == StateRateLimited && m.tokenPrompt {
-		if msg.String() == "enter" {
-			m.githubClient.SetToken(m.tokenInput)
-			m.state = StateActionReview
-			m.tokenPrompt = false
-			return m, m.resolveActions()
-		} else if msg.String() == "backspace" {
-			if len(m.tokenInput) > 0 {
-				m.tokenInput = m.tokenInput[:len(m.tokenInput)-1]
+	return m, nil
+}
+
+func (m Model) handleTokenKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	if m.validating {
+		return m, nil
+	}
+
+	if m.tokenInfo != nil {
+		switch msg.String() {
+		case "s":
+			if m.githubClient.ForwardsToken() {
+				// Only GitHub tokens are stored.
+				return m, nil
 			}
-		} else if len(msg.String()) == 1 {
-			m.tokenInput += msg.String()
+			if err := config.SaveToken(m.tokenInput.Value()); err != nil {
+				m.tokenErr = fmt.Errorf("failed to save token: %w", err)
+				return m, nil
+			}
+			path, _ := config.GetTokenPath()
+			m.message = fmt.Sprintf("Token saved to %s", path)
+			return m.resumeWithToken()
+		case "enter":
+			return m.resumeWithToken()
+		case "esc":
+			m.tokenInfo = nil
+			m.tokenInput.Focus()
+			return m, textinput.Blink
+		}
+		return m, nil
+	}
+
+	if !m.tokenPrompt {
+		switch msg.String() {
+		case "enter":
+			m.tokenPrompt = true
+			m.tokenErr = nil
+			m.tokenInput.Focus()
+			return m, textinput.Blink
+		case "q":
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.tokenPrompt = false
+		m.tokenInput.Blur()
+		return m, nil
+	case "enter":
+		token := strings.TrimSpace(m.tokenInput.Value())
+		if token == "" {
+			return m, nil
 		}
+		m.tokenInput.SetValue(token)
+		m.validating = true
+		m.tokenErr = nil
+		return m, tea.Batch(m.spinner.Tick, m.validateToken(token))
+	}
+
+	var cmd tea.Cmd
+	m.tokenInput, cmd = m.tokenInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) validateToken(token string) tea.Cmd {
+	return func() tea.Msg {
+		info, err := m.githubClient.ValidateToken(token)
+		return tokenValidatedMsg{info: info, err: err}
+	}
+}
+
+func (m Model) handleTokenValidated(msg tokenValidatedMsg) (tea.Model, tea.Cmd) {
+	m.validating = false
+	if msg.err != nil {
+		m.tokenErr = fmt.Errorf("token rejected: %w", msg.err)
+		return m, textinput.Blink
 	}
 
+	m.tokenInfo = msg.info
+	m.tokenInput.Blur()
 	return m, nil
 }
 
+func (m Model) resumeWithToken() (tea.Model, tea.Cmd) {
+	m.githubClient.SetToken(m.tokenInput.Value())
+	m.tokenPrompt = false
+	m.tokenInfo = nil
+	m.state = StateResolving
+	return m, tea.Batch(m.spinner.Tick, m.resolveActions(m.pending, m.pendingRetry))
+}
+
 func (m Model) handleEnterKey() (tea.Model, tea.Cmd) {
 	switch m.state {
 	case StateFileSelection:
@@ -145,6 +272,11 @@ func (m Model) handleEnterKey() (tea.Model, tea.Cmd) {
 			m.state = StateError
 			return m, nil
 		}
+		if err := m.checkCleanTree(); err != nil {
+			m.err = err
+			m.state = StateError
+			return m, nil
+		}
 		m.state = StateScanning
 		return m, tea.Batch(m.spinner.Tick, m.scanFiles())
 
@@ -154,16 +286,24 @@ func (m Model) handleEnterKey() (tea.Model, tea.Cmd) {
 			m.state = StateComplete
 			return m, nil
 		}
+		m.pending = m.selectedActions()
+		if len(m.pending) == 0 {
+			m.message = "No actions selected"
+			m.state = StateComplete
+			return m, nil
+		}
+		m.pendingRetry = false
 		m.state = StateResolving
-		return m, tea.Batch(m.spinner.Tick, m.resolveActions())
+		return m, tea.Batch(m.spinner.Tick, m.resolveActions(m.pending, false))
 
 	case StateConfirming:
+		if len(m.replacements) == 0 {
+			m.state = StateComplete
+			return m, nil
+		}
 		m.state = StateProcessing
 		return m, tea.Batch(m.spinner.Tick, m.processActions())
 
-	case StateRateLimited:
-		m.tokenPrompt = true
-		return m, nil
 	}
 
 	return m, nil
@@ -178,7 +318,7 @@ func (m Model) handleLoadingComplete(msg loadingCompleteMsg) (tea.Model, tea.Cmd
 
 	m.workflowFiles = msg.files
 	if len(m.workflowFiles) == 0 {
-		m.err = fmt.Errorf("no workflow files found in .github/workflows")
+		m.err = fmt.Errorf("no workflow files found in %s", strings.Join(m.workflowDirs, ", "))
 		m.state = StateError
 		return m, nil
 	}
@@ -221,7 +361,7 @@ func (m Model) handleScanComplete(msg scanCompleteMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	m.actions = unpinnedActions
+	m.setReviewActions(unpinnedActions)
 	m.state = StateActionReview
 	return m, nil
 }
@@ -238,12 +378,47 @@ func (m Model) handleResolveComplete(msg resolveCompleteMsg) (tea.Model, tea.Cmd
 		return m, nil
 	}
 
-	m.replacements = msg.replacements
+	// Results from before a rate limit interrupted this batch.
+	msg.replacements = append(m.partialRepls, msg.replacements...)
+	msg.failures = append(m.partialFails, msg.failures...)
+	m.partialRepls, m.partialFails = nil, nil
+
+	if msg.retry {
+		m.replacements = append(m.replacements, msg.replacements...)
+	} else {
+		m.replacements = msg.replacements
+	}
+	m.failures = msg.failures
 	m.totalCount = len(m.replacements)
+
+	if m.dryRun && len(m.replacements) > 0 {
+		changes, err := workflow.PlanChanges(m.root, m.replacements)
+		if err != nil {
+			m.err = err
+			m.state = StateError
+			return m, nil
+		}
+		m.changes = changes
+		content := renderDiff(changes)
+		m.diffView = viewport.New(100, min(strings.Count(content, "\n")+1, 20))
+		m.diffView.SetContent(content)
+	}
+
 	m.state = StateConfirming
 	return m, nil
 }
 
+func (m Model) retryFailures() (tea.Model, tea.Cmd) {
+	actions := make([]workflow.ActionReference, len(m.failures))
+	for i, f := range m.failures {
+		actions[i] = f.Action
+	}
+	m.pending = actions
+	m.pendingRetry = true
+	m.state = StateResolving
+	return m, tea.Batch(m.spinner.Tick, m.resolveActions(actions, true))
+}
+
 func (m Model) handleProcessComplete(msg processCompleteMsg) (tea.Model, tea.Cmd) {
 	if msg.err != nil {
 		m.err = msg.err
@@ -252,11 +427,17 @@ func (m Model) handleProcessComplete(msg processCompleteMsg) (tea.Model, tea.Cmd
 	}
 
 	m.backupPath = msg.backupPath
+	m.commitSHA = msg.commitSHA
+	m.prResult = msg.pr
 	m.state = StateComplete
 	return m, nil
 }
 
-func (m Model) handleRateLimit() (tea.Model, tea.Cmd) {
+func (m Model) handleRateLimit(msg rateLimitMsg) (tea.Model, tea.Cmd) {
+	m.partialRepls = append(m.partialRepls, msg.replacements...)
+	m.partialFails = append(m.partialFails, msg.failures...)
+	m.pending = msg.remaining
+	m.pendingRetry = msg.retry
 	m.state = StateRateLimited
 	return m, nil
 }
@@ -275,7 +456,7 @@ func (m Model) handleDeleteBackup() (tea.Model, tea.Cmd) {
 
 func (m Model) loadBackupList() (tea.Model, tea.Cmd) {
 	return m, func() tea.Msg {
-		backups, err := backup.ListBackups()
+		backups, err := backup.ListBackups(m.root)
 		return backupListMsg{backups: backups, err: err}
 	}
 }
@@ -324,7 +505,7 @@ func (m Model) handleRestoreBackup() (tea.Model, tea.Cmd) {
 		if bItem, ok := item.(backupItem); ok {
 			m.state = StateRestoring
 			return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
-				err := backup.RestoreBackup(bItem.info.Path)
+				err := backup.RestoreBackup(m.root, bItem.info.Path)
 				return restoreCompleteMsg{err: err}
 			})
 		}
@@ -373,7 +554,7 @@ func (m Model) scanFiles() tea.Cmd {
 	return func() tea.Msg {
 		var allActions []workflow.ActionReference
 		for _, file := range m.selectedFiles {
-			actions, err := workflow.ParseWorkflowFile(file)
+			actions, err := workflow.ParseWorkflowFile(m.root, file)
 			if err != nil {
 				return scanCompleteMsg{err: err}
 			}
@@ -383,18 +564,28 @@ func (m Model) scanFiles() tea.Cmd {
 	}
 }
 
-func (m Model) resolveActions() tea.Cmd {
+func (m Model) resolveActions(actions []workflow.ActionReference, retry bool) tea.Cmd {
 	return func() tea.Msg {
 		var replacements []workflow.Replacement
-		var lastError error
+		var failures []ResolveFailure
 
-		for _, action := range m.actions {
-			resolved := m.githubClient.ResolveAction(action.Owner, action.Repo, action.Ref)
+		for i, action := range actions {
+			resolved := m.githubClient.ResolveAction(action.Owner, action.Repo, m.targetRef(action))
 			if resolved.Error != nil {
-				lastError = resolved.Error
-				if github.IsRateLimitError(resolved.Error) {
-					return rateLimitMsg{}
+				reason := github.ClassifyResolveError(resolved.Error)
+				if reason == github.FailureRateLimit {
+					return rateLimitMsg{
+						replacements: replacements,
+						failures:     failures,
+						remaining:    actions[i:],
+						retry:        retry,
+					}
 				}
+				failures = append(failures, ResolveFailure{
+					Action: action,
+					Reason: reason,
+					Err:    resolved.Error,
+				})
 				continue
 			}
 
@@ -405,21 +596,44 @@ func (m Model) resolveActions() tea.Cmd {
 			})
 		}
 
-		if len(replacements) == 0 && lastError != nil {
-			return resolveCompleteMsg{err: lastError}
-		}
+		return resolveCompleteMsg{replacements: replacements, failures: failures, retry: retry}
+	}
+}
+
+func (m Model) checkCleanTree() error {
+	if !m.gitCommit || m.force || m.dryRun {
+		return nil
+	}
+
+	repo, err := git.Open(m.root)
+	if err != nil {
+		return err
+	}
 
-		return resolveCompleteMsg{replacements: replacements}
+	dirty, err := repo.DirtyFiles(m.selectedFiles)
+	if err != nil {
+		return err
 	}
+	if len(dirty) > 0 {
+		return fmt.Errorf("refusing to commit: uncommitted changes in %s (use --force to override)", strings.Join(dirty, ", "))
+	}
+	return nil
 }
 
 func (m Model) processActions() tea.Cmd {
+	if m.pullRequest != nil && !m.dryRun {
+		return m.processActionsWithPR()
+	}
+	if m.gitCommit && !m.dryRun {
+		return m.processActionsWithGit()
+	}
+
 	return func() tea.Msg {
 		var backupPath string
 		var err error
 
 		if !m.noBackup && !m.dryRun {
-			backupPath, err = backup.CreateBackup(m.selectedFiles)
+			backupPath, err = backup.CreateBackup(m.root, m.selectedFiles, m.version, m.backupLoc)
 			if err != nil {
 				return processCompleteMsg{err: err}
 			}
@@ -432,7 +646,7 @@ func (m Model) processActions() tea.Cmd {
 			}
 
 			for file, repls := range fileReplacements {
-				if err := workflow.ReplaceActionsInFile(file, repls); err != nil {
+				if err := workflow.ReplaceActionsInFile(m.root, file, repls); err != nil {
 					return processCompleteMsg{err: err}
 				}
 			}
@@ -441,3 +655,85 @@ func (m Model) processActions() tea.Cmd {
 		return processCompleteMsg{backupPath: backupPath}
 	}
 }
+
+func (m Model) processActionsWithGit() tea.Cmd {
+	return func() tea.Msg {
+		repo, err := git.Open(m.root)
+		if err != nil {
+			return processCompleteMsg{err: err}
+		}
+
+		// Everything that can fail up front is checked before the working
+		// tree or the current branch change.
+		changes, err := workflow.PlanChanges(m.root, m.replacements)
+		if err != nil {
+			return processCompleteMsg{err: err}
+		}
+		files := make([]string, len(changes))
+		for i, c := range changes {
+			if _, err := workflow.ParseWorkflowContent(c.New, c.Path); err != nil {
+				return processCompleteMsg{err: fmt.Errorf("pinning would break %s: %w", c.Path, err)}
+			}
+			files[i] = c.Path
+		}
+
+		var previous string
+		if m.gitBranch != "" {
+			if err := repo.CheckNewBranch(m.gitBranch); err != nil {
+				return processCompleteMsg{err: err}
+			}
+			if previous, err = repo.Head(); err != nil {
+				return processCompleteMsg{err: err}
+			}
+		}
+
+		if err := workflow.WriteChanges(m.root, changes); err != nil {
+			return processCompleteMsg{err: err}
+		}
+
+		if m.gitBranch != "" {
+			if err := repo.CreateBranch(m.gitBranch); err != nil {
+				return processCompleteMsg{err: errors.Join(err, workflow.RevertChanges(m.root, changes))}
+			}
+		}
+
+		sha, err := repo.Commit(files, git.CommitMessage(m.replacements))
+		if err != nil {
+			errs := []error{err, workflow.RevertChanges(m.root, changes)}
+			if m.gitBranch != "" {
+				errs = append(errs, repo.AbandonBranch(m.gitBranch, previous))
+			}
+			return processCompleteMsg{err: errors.Join(errs...)}
+		}
+
+		return processCompleteMsg{commitSHA: sha}
+	}
+}
+
+// processActionsWithPR proposes the planned changes as a pull request. The
+// working tree is left alone.
+func (m Model) processActionsWithPR() tea.Cmd {
+	return func() tea.Msg {
+		changes, err := workflow.PlanChanges(m.root, m.replacements)
+		if err != nil {
+			return processCompleteMsg{err: err}
+		}
+		for _, c := range changes {
+			if _, err := workflow.ParseWorkflowContent(c.New, c.Path); err != nil {
+				return processCompleteMsg{err: fmt.Errorf("pinning would break %s: %w", c.Path, err)}
+			}
+		}
+
+		failures := make([]remote.Failure, len(m.failures))
+		for i, f := range m.failures {
+			failures[i] = remote.Failure{Action: f.Action, Reason: f.Reason}
+		}
+
+		pr := m.pullRequest
+		result, err := pr.Pinner.Propose(m.githubClient.GetContext(), pr.Owner, pr.Repo, pr.Base, changes, m.replacements, failures)
+		if err != nil {
+			return processCompleteMsg{err: err}
+		}
+		return processCompleteMsg{pr: result.PR}
+	}
+}
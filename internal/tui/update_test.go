@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+// fakeResolver answers every ref with "sha-<repo>-<ref>" and reports a rate
+// limit for the repos in limited until they are removed.
+type fakeResolver struct {
+	calls   map[string]int
+	limited map[string]bool
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{calls: make(map[string]int), limited: make(map[string]bool)}
+}
+
+func (f *fakeResolver) Resolve(ctx context.Context, owner, repo, ref string) github.ResolvedAction {
+	f.calls[repo]++
+	if f.limited[repo] {
+		return github.ResolvedAction{Error: &github.ResolveError{Reason: github.FailureRateLimit, Err: errors.New("limited")}}
+	}
+	return github.ResolvedAction{SHA: "sha-" + repo + "-" + ref, Version: ref}
+}
+
+func testAction(repo string, line int) workflow.ActionReference {
+	return workflow.ActionReference{
+		Owner:    "actions",
+		Repo:     repo,
+		Ref:      "v1",
+		Line:     line,
+		FilePath: ".github/workflows/ci.yml",
+		FullUses: "actions/" + repo + "@v1",
+	}
+}
+
+func TestResolveResumesAfterRateLimit(t *testing.T) {
+	resolver := newFakeResolver()
+	resolver.limited["b"] = true
+	m := NewModel(github.NewClient("", github.WithResolver(resolver)), Options{})
+
+	actions := []workflow.ActionReference{testAction("a", 1), testAction("b", 2), testAction("c", 3)}
+	msg, ok := m.resolveActions(actions, false)().(rateLimitMsg)
+	if !ok {
+		t.Fatalf("expected rateLimitMsg")
+	}
+	if len(msg.replacements) != 1 || len(msg.remaining) != 2 {
+		t.Fatalf("got %d replacements and %d remaining, want 1 and 2", len(msg.replacements), len(msg.remaining))
+	}
+
+	updated, _ := m.Update(msg)
+	m = updated.(Model)
+	if m.state != StateRateLimited {
+		t.Fatalf("state = %v, want StateRateLimited", m.state)
+	}
+
+	delete(resolver.limited, "b")
+	updated, _ = m.Update(m.resolveActions(m.pending, m.pendingRetry)())
+	m = updated.(Model)
+
+	if len(m.replacements) != 3 {
+		t.Fatalf("got %d replacements after resuming, want 3", len(m.replacements))
+	}
+	if resolver.calls["a"] != 1 {
+		t.Errorf("resolved a %d times, want 1", resolver.calls["a"])
+	}
+	if resolver.calls["b"] != 2 || resolver.calls["c"] != 1 {
+		t.Errorf("calls = %v, want b twice and c once", resolver.calls)
+	}
+}
+
+func TestGitModeRollsBackWhenCommitFails(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	const content = "on: push\njobs:\n  build:\n    steps:\n      - uses: actions/checkout@v1\n"
+	file := filepath.Join(".github", "workflows", "ci.yml")
+	git("init", "-q", "-b", "main")
+	if err := os.MkdirAll(filepath.Join(dir, ".github", "workflows"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	if err := os.WriteFile(filepath.Join(dir, ".git", "hooks", "pre-commit"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	action := testAction("checkout", 5)
+	action.FilePath = file
+	m := NewModel(github.NewClient(""), Options{GitBranch: "pin-actions", Root: dir})
+	m.replacements = []workflow.Replacement{{Action: action, SHA: "0123456789012345678901234567890123456789", Version: "v1"}}
+
+	msg := m.processActionsWithGit()().(processCompleteMsg)
+	if msg.err == nil {
+		t.Fatal("expected the rejected commit to fail")
+	}
+
+	if branch := git("rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("left on branch %s, want main", branch)
+	}
+	if branches := git("branch", "--list", "pin-actions"); branches != "" {
+		t.Errorf("branch pin-actions was left behind")
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, file)); string(got) != content {
+		t.Errorf("workflow left modified:\n%s", got)
+	}
+}
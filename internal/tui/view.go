@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/thinesjs/gha-freeze/internal/diff"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
 )
 
 var (
@@ -28,6 +31,18 @@ var (
 	warningStyle = lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("214"))
+
+	diffAddStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("42"))
+
+	diffDelStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196"))
+
+	diffHunkStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("39"))
+
+	diffFileStyle = lipgloss.NewStyle().
+			Bold(true)
 )
 
 func (m Model) View() string {
@@ -56,6 +71,8 @@ func (m Model) View() string {
 		return m.viewError()
 	case StateRateLimited:
 		return m.viewRateLimited()
+	case StateVersionPicker:
+		return m.viewVersionPicker()
 	default:
 		return "Unknown state"
 	}
@@ -78,7 +95,7 @@ func (m Model) viewScanning() string {
 }
 
 func (m Model) viewResolving() string {
-	return fmt.Sprintf("\n%s Resolving %d actions to SHA commits via GitHub API...\n", m.spinner.View(), len(m.actions))
+	return fmt.Sprintf("\n%s Resolving %d actions to SHA commits via GitHub API...\n", m.spinner.View(), len(m.pending))
 }
 
 func (m Model) viewActionReview() string {
@@ -91,14 +108,80 @@ func (m Model) viewActionReview() string {
 		return b.String()
 	}
 
-	b.WriteString(fmt.Sprintf("Found %d unpinned actions:\n\n", len(m.actions)))
+	selected := len(m.selectedActions())
+	b.WriteString(fmt.Sprintf("Found %d unpinned actions, %d selected:\n\n", len(m.actions), selected))
+
+	if m.filtering || m.actionFilter.Value() != "" {
+		b.WriteString(m.actionFilter.View() + "\n\n")
+	}
+
+	visible := m.visibleActions()
+	if len(visible) == 0 {
+		b.WriteString(infoStyle.Render("  No actions match the filter") + "\n")
+	}
+
+	start := 0
+	if m.actionCursor >= reviewPageSize {
+		start = m.actionCursor - reviewPageSize + 1
+	}
+	end := min(start+reviewPageSize, len(visible))
+
+	lastFile := ""
+	for pos := start; pos < end; pos++ {
+		idx := visible[pos]
+		action := m.actions[idx]
+
+		if action.FilePath != lastFile {
+			b.WriteString(titleStyle.Render(action.FilePath) + "\n")
+			lastFile = action.FilePath
+		}
+
+		cursor := "  "
+		if pos == m.actionCursor {
+			cursor = "> "
+		}
+		checkbox := "[ ]"
+		if m.actionSelected[idx] {
+			checkbox = "[✓]"
+		}
+		ref := action.Ref
+		if target := m.targetRef(action); target != action.Ref {
+			ref = fmt.Sprintf("%s → %s", action.Ref, target)
+		}
+		line := fmt.Sprintf("%s%s %s/%s@%s (line %d)", cursor, checkbox, action.Owner, action.Repo, ref, action.Line)
+		if pos == m.actionCursor {
+			line = successStyle.Render(line)
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if len(visible) > end-start {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  (%d-%d of %d)", start+1, end, len(visible))) + "\n")
+	}
 
-	for _, action := range m.actions {
-		b.WriteString(fmt.Sprintf("  %s/%s@%s (%s:%d)\n",
-			action.Owner, action.Repo, action.Ref, action.FilePath, action.Line))
+	if m.filtering {
+		b.WriteString("\n" + infoStyle.Render("type to filter • enter: apply • esc: clear"))
+	} else {
+		b.WriteString("\n" + infoStyle.Render("↑/↓: navigate • space: toggle • a: toggle all shown • o: toggle owner • v: pick version • /: filter • enter: resolve selected • q: quit"))
 	}
+	return b.String()
+}
 
-	b.WriteString("\n" + infoStyle.Render("Press Enter to resolve and pin these actions, q to quit"))
+func (m Model) viewVersionPicker() string {
+	var b strings.Builder
+	action := m.actions[m.versionTarget]
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Versions of %s/%s", action.Owner, action.Repo)) + "\n\n")
+
+	switch {
+	case m.versionErr != nil:
+		b.WriteString(errorStyle.Render("Failed to list versions: "+m.versionErr.Error()) + "\n")
+		b.WriteString("\n" + infoStyle.Render("esc: back"))
+	case len(m.versionList.Items()) == 0:
+		b.WriteString(fmt.Sprintf("%s Loading tags and releases...\n", m.spinner.View()))
+	default:
+		b.WriteString(m.versionList.View())
+		b.WriteString("\n\n" + infoStyle.Render("↑/↓: navigate • /: filter • enter: pin to this version • esc: back"))
+	}
 	return b.String()
 }
 
@@ -108,7 +191,12 @@ func (m Model) viewConfirming() string {
 
 	if len(m.replacements) == 0 {
 		b.WriteString(warningStyle.Render("No actions could be resolved") + "\n")
-		b.WriteString("\n" + infoStyle.Render("Press Enter to exit"))
+		b.WriteString(m.viewFailures())
+		if len(m.failures) > 0 {
+			b.WriteString("\n" + infoStyle.Render("Press t to retry failed actions, Enter to exit"))
+		} else {
+			b.WriteString("\n" + infoStyle.Render("Press Enter to exit"))
+		}
 		return b.String()
 	}
 
@@ -120,11 +208,47 @@ func (m Model) viewConfirming() string {
 			repl.Action.Owner, repl.Action.Repo, repl.SHA, repl.Version))
 	}
 
+	b.WriteString(m.viewFailures())
+
+	if m.dryRun && len(m.changes) > 0 {
+		b.WriteString(titleStyle.Render(fmt.Sprintf("Changes (%d files)", len(m.changes))) + "\n\n")
+		b.WriteString(m.diffView.View() + "\n")
+		if m.diffView.TotalLineCount() > m.diffView.Height {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("↑/↓: scroll diff (%3.f%%)", m.diffView.ScrollPercent()*100)) + "\n")
+		}
+		b.WriteString("\n")
+	}
+
 	if m.dryRun {
 		b.WriteString(warningStyle.Render("DRY RUN MODE - No changes will be made") + "\n")
 	}
+	if m.pullRequest != nil && !m.dryRun {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("Will open a pull request on %s/%s; local files are not modified", m.pullRequest.Owner, m.pullRequest.Repo)) + "\n")
+	}
 
-	b.WriteString("\n" + infoStyle.Render("Press Enter to confirm, q to cancel"))
+	if len(m.failures) > 0 {
+		b.WriteString("\n" + infoStyle.Render("Press Enter to confirm, t to retry failed actions, q to cancel"))
+	} else {
+		b.WriteString("\n" + infoStyle.Render("Press Enter to confirm, q to cancel"))
+	}
+	return b.String()
+}
+
+func (m Model) viewFailures() string {
+	if len(m.failures) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(errorStyle.Render(fmt.Sprintf("Could not resolve %d actions:", len(m.failures))) + "\n\n")
+	for _, f := range m.failures {
+		b.WriteString(fmt.Sprintf("  %s/%s@%s (%s:%d)\n    ✗ %s\n",
+			f.Action.Owner, f.Action.Repo, f.Action.Ref, f.Action.FilePath, f.Action.Line, f.Reason))
+		if f.Err != nil {
+			b.WriteString(infoStyle.Render("      "+f.Err.Error()) + "\n")
+		}
+		b.WriteString("\n")
+	}
 	return b.String()
 }
 
@@ -141,11 +265,34 @@ func (m Model) viewComplete() string {
 	} else {
 		b.WriteString(fmt.Sprintf("Pinned %d actions\n", len(m.replacements)))
 
+		switch {
+		case m.prResult != nil && m.prResult.Updated:
+			b.WriteString(fmt.Sprintf("Updated pull request #%d: %s\n\n", m.prResult.Number, m.prResult.URL))
+		case m.prResult != nil:
+			b.WriteString(fmt.Sprintf("Opened pull request #%d: %s\n\n", m.prResult.Number, m.prResult.URL))
+		}
+
+		if m.commitSHA != "" {
+			if m.gitBranch != "" {
+				b.WriteString(fmt.Sprintf("Committed %s on branch %s\n\n", shortSHA(m.commitSHA), m.gitBranch))
+			} else {
+				b.WriteString(fmt.Sprintf("Committed %s\n\n", shortSHA(m.commitSHA)))
+			}
+		}
+
 		if m.backupPath != "" {
 			b.WriteString(fmt.Sprintf("Backup created at: %s\n\n", m.backupPath))
 		}
 	}
 
+	if len(m.failures) > 0 {
+		b.WriteString(warningStyle.Render(fmt.Sprintf("Left %d actions unpinned:", len(m.failures))) + "\n")
+		for _, f := range m.failures {
+			b.WriteString(fmt.Sprintf("  %s/%s@%s (%s:%d) - %s\n",
+				f.Action.Owner, f.Action.Repo, f.Action.Ref, f.Action.FilePath, f.Action.Line, f.Reason))
+		}
+	}
+
 	if m.message != "" {
 		b.WriteString("\n" + successStyle.Render(m.message) + "\n")
 	}
@@ -185,10 +332,35 @@ func (m Model) viewRateLimited() string {
 	var b strings.Builder
 	b.WriteString(warningStyle.Render("GitHub API Rate Limit Reached") + "\n\n")
 
-	if m.tokenPrompt {
-		b.WriteString("Enter GitHub Personal Access Token:\n")
-		b.WriteString(strings.Repeat("*", len(m.tokenInput)) + "\n")
-		b.WriteString("\n" + infoStyle.Render("Press Enter when done, Ctrl+C to cancel"))
+	if m.tokenInfo != nil {
+		b.WriteString(successStyle.Render("✓ Token is valid") + "\n\n")
+		b.WriteString(fmt.Sprintf("  User:       %s\n", m.tokenInfo.Login))
+		scopes := "(none)"
+		if len(m.tokenInfo.Scopes) > 0 {
+			scopes = strings.Join(m.tokenInfo.Scopes, ", ")
+		}
+		b.WriteString(fmt.Sprintf("  Scopes:     %s\n", scopes))
+		if m.tokenInfo.Limit > 0 {
+			b.WriteString(fmt.Sprintf("  Rate limit: %d/%d remaining (resets %s)\n",
+				m.tokenInfo.Remaining, m.tokenInfo.Limit, m.tokenInfo.Reset.Local().Format("15:04")))
+		}
+		if m.tokenErr != nil {
+			b.WriteString("\n" + errorStyle.Render(m.tokenErr.Error()) + "\n")
+		}
+		if m.githubClient.ForwardsToken() {
+			b.WriteString("\n" + infoStyle.Render("enter: continue • esc: edit token"))
+		} else {
+			b.WriteString("\n" + infoStyle.Render("s: save token and continue • enter: continue without saving • esc: edit token"))
+		}
+	} else if m.tokenPrompt {
+		b.WriteString("Enter GitHub Personal Access Token:\n\n")
+		b.WriteString(m.tokenInput.View() + "\n")
+		if m.validating {
+			b.WriteString(fmt.Sprintf("\n%s Validating token...\n", m.spinner.View()))
+		} else if m.tokenErr != nil {
+			b.WriteString("\n" + errorStyle.Render(m.tokenErr.Error()) + "\n")
+		}
+		b.WriteString("\n" + infoStyle.Render("enter: validate • esc: back • ctrl+c: quit"))
 	} else {
 		timestamp := time.Now().Format("2006-01-02")
 		description := fmt.Sprintf("gha-freeze-%s", timestamp)
@@ -198,9 +370,39 @@ func (m Model) viewRateLimited() string {
 		b.WriteString("You've hit the GitHub API rate limit for unauthenticated requests.\n\n")
 		b.WriteString("Create a token with public_repo scope:\n")
 		b.WriteString(tokenURL + "\n\n")
-		b.WriteString("Save it for future use: gha-freeze auth YOUR_TOKEN\n\n")
+		b.WriteString("Save it for future use: gha-freeze auth login\n\n")
 		b.WriteString(infoStyle.Render("Press Enter to provide a GitHub token, q to quit"))
 	}
 
 	return b.String()
 }
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func renderDiff(changes []workflow.FileChange) string {
+	var b strings.Builder
+	for _, c := range changes {
+		patch := diff.GitPatch(c.Path, c.Old, c.New)
+		for _, line := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "diff --git"), strings.HasPrefix(line, "--- "), strings.HasPrefix(line, "+++ "):
+				b.WriteString(diffFileStyle.Render(line))
+			case strings.HasPrefix(line, "@@"):
+				b.WriteString(diffHunkStyle.Render(line))
+			case strings.HasPrefix(line, "+"):
+				b.WriteString(diffAddStyle.Render(line))
+			case strings.HasPrefix(line, "-"):
+				b.WriteString(diffDelStyle.Render(line))
+			default:
+				b.WriteString(line)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,154 @@
+package updater
+
+import (
+	"fmt"
+	"go/build"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+type InstallMethod string
+
+const (
+	InstallRelease   InstallMethod = "release binary"
+	InstallGo        InstallMethod = "go install"
+	InstallHomebrew  InstallMethod = "Homebrew"
+	defaultGoPackage               = "github.com/thinesjs/gha-freeze/cmd/gha-freeze"
+)
+
+// Installation describes how the running executable was installed.
+type Installation struct {
+	Path     string
+	Method   InstallMethod
+	Writable bool
+}
+
+// Managed reports whether a package manager owns the executable, in which
+// case it must be upgraded through that package manager.
+func (i *Installation) Managed() bool {
+	return i.Method != InstallRelease
+}
+
+// UpgradeCommand is the command users should run instead of self-updating.
+func (i *Installation) UpgradeCommand() string {
+	switch i.Method {
+	case InstallGo:
+		pkg := defaultGoPackage
+		if bi, ok := debug.ReadBuildInfo(); ok && bi.Path != "" {
+			pkg = bi.Path
+		}
+		return "go install " + pkg + "@latest"
+	case InstallHomebrew:
+		return "brew upgrade gha-freeze"
+	}
+	return "gha-freeze update"
+}
+
+// DetectInstallation inspects the executable path and build info to find
+// out how gha-freeze was installed and whether it can replace itself.
+func DetectInstallation() (*Installation, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return nil, err
+	}
+	return detectInstallation(exe), nil
+}
+
+func detectInstallation(exe string) *Installation {
+	inst := &Installation{
+		Path:     exe,
+		Method:   InstallRelease,
+		Writable: checkWritable(exe) == nil,
+	}
+
+	switch {
+	case isHomebrewPath(exe):
+		inst.Method = InstallHomebrew
+	case isGoInstall(exe):
+		inst.Method = InstallGo
+	}
+	return inst
+}
+
+func isHomebrewPath(exe string) bool {
+	path := filepath.ToSlash(exe)
+	if strings.Contains(path, "/Cellar/") || strings.Contains(path, "/.linuxbrew/") {
+		return true
+	}
+
+	if prefix := os.Getenv("HOMEBREW_PREFIX"); prefix != "" {
+		return strings.HasPrefix(path, filepath.ToSlash(prefix)+"/")
+	}
+	return false
+}
+
+// isGoInstall recognises binaries built by "go install module@version":
+// they carry a module version but no VCS stamp, because the module cache is
+// not a checkout. Binaries sitting in GOBIN or GOPATH/bin are treated the
+// same way.
+func isGoInstall(exe string) bool {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		version := bi.Main.Version
+		if version != "" && version != "(devel)" && !hasVCSInfo(bi) {
+			return true
+		}
+	}
+
+	dir := filepath.Dir(exe)
+	for _, binDir := range goBinDirs() {
+		if sameDir(dir, binDir) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasVCSInfo(bi *debug.BuildInfo) bool {
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.revision" {
+			return true
+		}
+	}
+	return false
+}
+
+func goBinDirs() []string {
+	var dirs []string
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		dirs = append(dirs, gobin)
+	}
+
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		gopath = build.Default.GOPATH
+	}
+	for _, p := range filepath.SplitList(gopath) {
+		if p != "" {
+			dirs = append(dirs, filepath.Join(p, "bin"))
+		}
+	}
+	return dirs
+}
+
+func sameDir(a, b string) bool {
+	if resolved, err := filepath.EvalSymlinks(b); err == nil {
+		b = resolved
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// checkWritable makes sure the executable can be swapped: replacing it
+// needs write access to its directory.
+func checkWritable(exe string) error {
+	dir := filepath.Dir(exe)
+
+	f, err := os.CreateTemp(dir, ".gha-freeze-write-check-*")
+	if err != nil {
+		return fmt.Errorf("cannot replace %s: %s is not writable (re-run with elevated permissions or reinstall to a user-writable directory)", exe, dir)
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,139 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// noGoBin points GOBIN and GOPATH away from the directories under test.
+func noGoBin(t *testing.T) {
+	t.Helper()
+	t.Setenv("GOBIN", "")
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("HOMEBREW_PREFIX", "")
+}
+
+func TestIsHomebrewPath(t *testing.T) {
+	noGoBin(t)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/opt/homebrew/Cellar/gha-freeze/1.2.0/bin/gha-freeze", true},
+		{"/usr/local/Cellar/gha-freeze/1.2.0/bin/gha-freeze", true},
+		{"/home/linuxbrew/.linuxbrew/bin/gha-freeze", true},
+		{"/home/me/.linuxbrew/Cellar/gha-freeze/1.2.0/bin/gha-freeze", true},
+		{"/usr/local/bin/gha-freeze", false},
+		{"/home/me/cellar/gha-freeze", false},
+	}
+	for _, tt := range tests {
+		if got := isHomebrewPath(tt.path); got != tt.want {
+			t.Errorf("isHomebrewPath(%s) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	t.Setenv("HOMEBREW_PREFIX", "/opt/brew")
+	if !isHomebrewPath("/opt/brew/bin/gha-freeze") {
+		t.Error("binary under HOMEBREW_PREFIX not recognised")
+	}
+	if isHomebrewPath("/opt/brewery/bin/gha-freeze") {
+		t.Error("HOMEBREW_PREFIX matched a sibling directory")
+	}
+}
+
+func TestIsGoInstall(t *testing.T) {
+	gobin := t.TempDir()
+	gopath := t.TempDir()
+	other := t.TempDir()
+
+	tests := []struct {
+		name          string
+		gobin, gopath string
+		dir           string
+		want          bool
+	}{
+		{"in GOBIN", gobin, gopath, gobin, true},
+		{"in GOPATH/bin", "", gopath, filepath.Join(gopath, "bin"), true},
+		{"in the second GOPATH entry", "", other + string(filepath.ListSeparator) + gopath, filepath.Join(gopath, "bin"), true},
+		{"elsewhere", gobin, gopath, other, false},
+		{"GOPATH itself", "", gopath, gopath, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOBIN", tt.gobin)
+			t.Setenv("GOPATH", tt.gopath)
+			if got := isGoInstall(filepath.Join(tt.dir, "gha-freeze")); got != tt.want {
+				t.Errorf("isGoInstall = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectInstallation(t *testing.T) {
+	noGoBin(t)
+	gobin := t.TempDir()
+
+	tests := []struct {
+		name string
+		exe  string
+		want InstallMethod
+	}{
+		{"release", filepath.Join(t.TempDir(), "gha-freeze"), InstallRelease},
+		{"homebrew", "/opt/homebrew/Cellar/gha-freeze/1.2.0/bin/gha-freeze", InstallHomebrew},
+		{"go install", filepath.Join(gobin, "gha-freeze"), InstallGo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GOBIN", gobin)
+			inst := detectInstallation(tt.exe)
+			if inst.Method != tt.want || inst.Path != tt.exe {
+				t.Errorf("detectInstallation = %+v, want %s", inst, tt.want)
+			}
+			if inst.Managed() != (tt.want != InstallRelease) {
+				t.Errorf("Managed() = %v for %s", inst.Managed(), tt.want)
+			}
+		})
+	}
+
+	if inst := detectInstallation(filepath.Join(gobin, "gha-freeze")); !inst.Writable {
+		t.Error("executable in a writable directory reported as not writable")
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkWritable(filepath.Join(dir, "gha-freeze")); err != nil {
+		t.Errorf("writable directory: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("write check left %d files behind", len(entries))
+	}
+
+	if err := checkWritable(filepath.Join(dir, "missing", "gha-freeze")); err == nil {
+		t.Error("missing directory reported as writable")
+	}
+	notDir := filepath.Join(dir, "file")
+	if err := os.WriteFile(notDir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(filepath.Join(notDir, "gha-freeze")); err == nil {
+		t.Error("path below a file reported as writable")
+	}
+
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced")
+	}
+	readOnly := filepath.Join(dir, "read-only")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(filepath.Join(readOnly, "gha-freeze")); err == nil {
+		t.Error("read-only directory reported as writable")
+	}
+	if inst := detectInstallation(filepath.Join(readOnly, "gha-freeze")); inst.Writable {
+		t.Error("detectInstallation reported a read-only directory as writable")
+	}
+}
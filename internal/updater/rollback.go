@@ -0,0 +1,228 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/thinesjs/gha-freeze/internal/config"
+)
+
+const (
+	previousDir      = "previous"
+	previousManifest = "previous.json"
+	smokeTestTimeout = 10 * time.Second
+)
+
+// PreviousBinary describes the binary kept around by the last update so it
+// can be restored with Rollback.
+type PreviousBinary struct {
+	Version string    `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+func previousPaths() (dir, binary, manifest string, err error) {
+	state, err := config.StateDir()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	name := "gha-freeze"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+
+	dir = filepath.Join(state, previousDir)
+	return dir, filepath.Join(dir, name), filepath.Join(dir, previousManifest), nil
+}
+
+// LoadPreviousBinary returns the retained binary, or nil if none is kept.
+func LoadPreviousBinary() (*PreviousBinary, error) {
+	_, binary, manifest, err := previousPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(manifest)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var prev PreviousBinary
+	if err := json.Unmarshal(data, &prev); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", previousManifest, err)
+	}
+
+	if _, err := os.Stat(binary); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &prev, nil
+}
+
+// retainPrevious copies the replaced executable into the state directory
+// together with the version it reported.
+func retainPrevious(exePath, version string) error {
+	dir, binary, manifest, err := previousPaths()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	tmp := binary + ".tmp"
+	if err := copyExecutable(exePath, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, binary); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	data, err := json.MarshalIndent(PreviousBinary{Version: version, SavedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifest, append(data, '\n'), 0600)
+}
+
+// Rollback swaps the running executable with the retained previous binary.
+// The binary being replaced is retained in turn, so a second rollback
+// returns to it. It returns the version that was restored.
+func Rollback(currentVersion string) (string, error) {
+	currentExe, err := executablePath()
+	if err != nil {
+		return "", err
+	}
+	return rollback(currentExe, currentVersion)
+}
+
+func rollback(currentExe, currentVersion string) (string, error) {
+	prev, err := LoadPreviousBinary()
+	if err != nil {
+		return "", err
+	}
+	if prev == nil {
+		return "", fmt.Errorf("no previous version available to roll back to")
+	}
+
+	_, binary, _, err := previousPaths()
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkWritable(currentExe); err != nil {
+		return "", err
+	}
+
+	staged := currentExe + ".new"
+	if err := copyExecutable(binary, staged); err != nil {
+		return "", fmt.Errorf("failed to stage previous binary: %w", err)
+	}
+
+	backupPath, err := swapExecutable(currentExe, staged)
+	if err != nil {
+		_ = os.Remove(staged)
+		return "", err
+	}
+
+	if err := smokeTest(currentExe); err != nil {
+		_ = restoreExecutable(currentExe, backupPath)
+		return "", fmt.Errorf("previous binary failed to start: %w", err)
+	}
+
+	if err := retainPrevious(backupPath, currentVersion); err != nil {
+		_ = os.Remove(backupPath)
+		return "", fmt.Errorf("rolled back, but failed to retain %s: %w", currentVersion, err)
+	}
+	_ = os.Remove(backupPath)
+
+	return prev.Version, nil
+}
+
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current executable path: %w", err)
+	}
+
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve executable path: %w", err)
+	}
+	return exe, nil
+}
+
+// swapExecutable moves currentExe aside to currentExe+".old" and puts
+// replacement in its place. It returns the path of the moved-aside binary.
+func swapExecutable(currentExe, replacement string) (string, error) {
+	backupPath := currentExe + ".old"
+	_ = os.Remove(backupPath)
+
+	if err := os.Rename(currentExe, backupPath); err != nil {
+		return "", fmt.Errorf("failed to backup current executable: %w", err)
+	}
+
+	if err := os.Rename(replacement, currentExe); err != nil {
+		_ = os.Rename(backupPath, currentExe)
+		return "", fmt.Errorf("failed to install new executable: %w", err)
+	}
+
+	if err := os.Chmod(currentExe, 0755); err != nil {
+		_ = restoreExecutable(currentExe, backupPath)
+		return "", fmt.Errorf("failed to set executable permissions: %w", err)
+	}
+
+	return backupPath, nil
+}
+
+func restoreExecutable(currentExe, backupPath string) error {
+	if err := os.Remove(currentExe); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(backupPath, currentExe)
+}
+
+// smokeTest runs "<exe> version" and checks that it starts and identifies
+// itself.
+func smokeTest(exe string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), smokeTestTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, exe, "version")
+	cmd.Env = append(os.Environ(), "GHA_FREEZE_NO_UPDATE_CHECK=1")
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	if !strings.Contains(string(out), "gha-freeze version") {
+		return fmt.Errorf("unexpected output from version command: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func copyExecutable(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0755)
+}
@@ -0,0 +1,190 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeExecutable writes a script that prints output for "version" and
+// exits with code, standing in for a gha-freeze binary.
+func fakeExecutable(t *testing.T, path, output string, code int) {
+	t.Helper()
+	script := "#!/bin/sh\necho '" + output + "'\nexit " + strconv.Itoa(code) + "\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rollbackEnv points the state directory at a temporary directory and
+// returns the path of a temporary "installed" executable.
+func rollbackEnv(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake executables are shell scripts")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	return filepath.Join(t.TempDir(), "gha-freeze")
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRollbackRestoresPrevious(t *testing.T) {
+	exe := rollbackEnv(t)
+	old := filepath.Join(t.TempDir(), "old")
+	fakeExecutable(t, old, "gha-freeze version 1.0.0", 0)
+	if err := retainPrevious(old, "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	fakeExecutable(t, exe, "gha-freeze version 1.1.0", 0)
+	installed := readFile(t, exe)
+
+	restored, err := rollback(exe, "1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != "1.0.0" {
+		t.Errorf("rollback restored %s, want 1.0.0", restored)
+	}
+	if got := readFile(t, exe); got != readFile(t, old) {
+		t.Errorf("executable after rollback:\n%s\nwant the previous binary", got)
+	}
+	if _, err := os.Stat(exe + ".old"); !os.IsNotExist(err) {
+		t.Errorf("moved-aside binary left behind: %v", err)
+	}
+
+	// The replaced binary is retained, so rolling back again returns to it.
+	prev, err := LoadPreviousBinary()
+	if err != nil || prev == nil || prev.Version != "1.1.0" {
+		t.Fatalf("previous binary after rollback = %+v, %v; want 1.1.0", prev, err)
+	}
+	if restored, err := rollback(exe, "1.0.0"); err != nil || restored != "1.1.0" {
+		t.Fatalf("second rollback = %s, %v; want 1.1.0", restored, err)
+	}
+	if got := readFile(t, exe); got != installed {
+		t.Errorf("executable after second rollback:\n%s\nwant the 1.1.0 binary", got)
+	}
+}
+
+func TestRollbackFailingSmokeTestKeepsCurrent(t *testing.T) {
+	exe := rollbackEnv(t)
+	broken := filepath.Join(t.TempDir(), "broken")
+	fakeExecutable(t, broken, "segmentation fault", 1)
+	if err := retainPrevious(broken, "1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	fakeExecutable(t, exe, "gha-freeze version 1.1.0", 0)
+	installed := readFile(t, exe)
+
+	_, err := rollback(exe, "1.1.0")
+	if err == nil || !strings.Contains(err.Error(), "failed to start") {
+		t.Fatalf("rollback error = %v, want the smoke test to fail", err)
+	}
+	if got := readFile(t, exe); got != installed {
+		t.Errorf("executable after a failed rollback:\n%s\nwant the original", got)
+	}
+	if err := smokeTest(exe); err != nil {
+		t.Errorf("original no longer starts: %v", err)
+	}
+	if prev, err := LoadPreviousBinary(); err != nil || prev == nil || prev.Version != "1.0.0" {
+		t.Errorf("previous binary after a failed rollback = %+v, %v; want 1.0.0 kept", prev, err)
+	}
+}
+
+func TestRollbackWithoutPrevious(t *testing.T) {
+	exe := rollbackEnv(t)
+	fakeExecutable(t, exe, "gha-freeze version 1.1.0", 0)
+
+	if _, err := rollback(exe, "1.1.0"); err == nil || !strings.Contains(err.Error(), "no previous version") {
+		t.Errorf("rollback error = %v, want no previous version", err)
+	}
+}
+
+func TestRetainPreviousKeepsOneVersion(t *testing.T) {
+	rollbackEnv(t)
+	src := t.TempDir()
+	for _, v := range []string{"1.0.0", "1.1.0", "1.2.0"} {
+		bin := filepath.Join(src, v)
+		fakeExecutable(t, bin, "gha-freeze version "+v, 0)
+		if err := retainPrevious(bin, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, binary, manifest, err := previousPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{filepath.Base(binary), filepath.Base(manifest)}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("state directory holds %v, want only %v", names, want)
+	}
+
+	prev, err := LoadPreviousBinary()
+	if err != nil || prev == nil || prev.Version != "1.2.0" {
+		t.Fatalf("LoadPreviousBinary = %+v, %v; want 1.2.0", prev, err)
+	}
+	if got := readFile(t, binary); !strings.Contains(got, "1.2.0") {
+		t.Errorf("retained binary is not the last one:\n%s", got)
+	}
+}
+
+func TestSwapExecutable(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "gha-freeze")
+	staged := exe + ".new"
+	if err := os.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(staged, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := swapExecutable(exe, staged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, exe); got != "new" {
+		t.Errorf("executable = %q, want the replacement", got)
+	}
+	if got := readFile(t, backup); got != "old" {
+		t.Errorf("backup = %q, want the original", got)
+	}
+	if info, err := os.Stat(exe); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm()&0100 == 0 {
+		t.Errorf("replacement mode = %v, want it executable", info.Mode())
+	}
+
+	if err := restoreExecutable(exe, backup); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable after restore = %q, want the original", got)
+	}
+
+	if _, err := swapExecutable(exe, filepath.Join(dir, "missing")); err == nil {
+		t.Error("swapExecutable succeeded without a replacement")
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable after a failed swap = %q, want the original", got)
+	}
+}
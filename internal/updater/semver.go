@@ -0,0 +1,115 @@
+package updater
+
+import (
+	"strconv"
+	"strings"
+)
+
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+}
+
+func parseSemver(v string) (semver, bool) {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+
+	var sv semver
+	core := v
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		core = v[:i]
+		sv.prerelease = strings.Split(v[i+1:], ".")
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+
+	nums := make([]int, 3)
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+
+	sv.major, sv.minor, sv.patch = nums[0], nums[1], nums[2]
+	return sv, true
+}
+
+func (a semver) compare(b semver) int {
+	for _, d := range []int{a.major - b.major, a.minor - b.minor, a.patch - b.patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		if c := comparePrereleaseIdent(a.prerelease[i], b.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return sign(len(a.prerelease) - len(b.prerelease))
+}
+
+func comparePrereleaseIdent(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+
+	switch {
+	case aErr == nil && bErr == nil:
+		return sign(an - bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func isPrerelease(v string) bool {
+	sv, ok := parseSemver(v)
+	return ok && len(sv.prerelease) > 0
+}
+
+func compareVersions(current, latest string) int {
+	current = strings.TrimPrefix(current, "v")
+	latest = strings.TrimPrefix(latest, "v")
+
+	if current == latest {
+		return 0
+	}
+	if current == "dev" || current == "" {
+		return -1
+	}
+
+	a, aOK := parseSemver(current)
+	b, bOK := parseSemver(latest)
+	if !aOK || !bOK {
+		return strings.Compare(current, latest)
+	}
+	return a.compare(b)
+}
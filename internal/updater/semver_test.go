@@ -0,0 +1,87 @@
+package updater
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            int
+	}{
+		{"0.0.9", "0.0.10", -1},
+		{"0.0.10", "0.0.9", 1},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "10.0.0", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.0-rc.1", "1.0.0-rc.2", -1},
+		{"1.0.0-rc.2", "1.0.0-rc.10", -1},
+		// Numeric identifiers sort before alphanumeric ones.
+		{"1.0.0-1", "1.0.0-alpha", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0+build.5", "1.0.0", 0},
+		{"dev", "0.0.1", -1},
+		{"", "0.0.1", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.current, tt.latest); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+// staticReleases is a release source with a fixed list of releases.
+type staticReleases []release
+
+func (s staticReleases) listReleases(ctx context.Context) ([]release, error) { return s, nil }
+
+func (s staticReleases) releaseByTag(ctx context.Context, tag string) (*release, error) {
+	return nil, nil
+}
+
+func (s staticReleases) downloadToken() string { return "" }
+
+func TestGetNewestRelease(t *testing.T) {
+	releases := staticReleases{
+		{Tag: "v0.0.9"},
+		{Tag: "v0.0.10"},
+		{Tag: "v0.1.0-rc.1"},
+		{Tag: "v0.0.11", Prerelease: true},
+		{Tag: "v0.2.0", Draft: true},
+		{Tag: "nightly"},
+	}
+
+	tests := []struct {
+		name     string
+		releases staticReleases
+		channel  Channel
+		want     string
+	}{
+		{"stable skips prereleases", releases, ChannelStable, "v0.0.10"},
+		{"default channel is stable", releases, "", "v0.0.10"},
+		{"beta includes prereleases", releases, ChannelBeta, "v0.1.0-rc.1"},
+		{"beta prefers the final release", append(releases, release{Tag: "v0.1.0"}), ChannelBeta, "v0.1.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getNewestRelease(t.Context(), tt.releases, tt.channel)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Tag != tt.want {
+				t.Errorf("getNewestRelease = %s, want %s", got.Tag, tt.want)
+			}
+		})
+	}
+
+	if _, err := getNewestRelease(t.Context(), staticReleases{{Tag: "v1.0.0-beta.1"}}, ChannelStable); err == nil {
+		t.Error("getNewestRelease found a stable release among prereleases only")
+	}
+}
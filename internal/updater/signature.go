@@ -0,0 +1,95 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// releasePublicKey is the minisign public key (the base64 line of the .pub
+// file) that checksums.txt is signed with. It is embedded in every build, so
+// snapshot and development builds verify updates the same way releases do.
+// Its key ID is A11B06E25AC579E9; minisign prints the same ID for the
+// MINISIGN_SECRET_KEY secret the release workflow signs with.
+const releasePublicKey = "RWTpecVa4gYbocf2xtGmrp8DFb5t2CiuXbEgJiAtjEJY+/0BfZ0DrNnj"
+
+const signatureSuffix = ".minisig"
+
+type minisignPublicKey struct {
+	keyID [8]byte
+	key   ed25519.PublicKey
+}
+
+func parsePublicKey(s string) (*minisignPublicKey, error) {
+	line := lastNonCommentLine(s)
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 42 || string(raw[:2]) != "Ed" {
+		return nil, fmt.Errorf("invalid minisign public key")
+	}
+
+	pk := &minisignPublicKey{key: ed25519.PublicKey(raw[10:])}
+	copy(pk.keyID[:], raw[2:10])
+	return pk, nil
+}
+
+func lastNonCommentLine(s string) string {
+	line := ""
+	for _, l := range strings.Split(strings.TrimSpace(s), "\n") {
+		l = strings.TrimSpace(l)
+		if l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+		}
+	}
+	return line
+}
+
+func verifySignature(publicKey string, message, signature []byte) error {
+	pk, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(signature), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return fmt.Errorf("malformed signature file")
+	}
+
+	sigRaw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sigRaw) != 74 {
+		return fmt.Errorf("malformed signature")
+	}
+
+	if !bytes.Equal(sigRaw[2:10], pk.keyID[:]) {
+		return fmt.Errorf("signature was made with a different key (key ID %X)", sigRaw[2:10])
+	}
+
+	sig := sigRaw[10:]
+	switch string(sigRaw[:2]) {
+	case "Ed":
+	case "ED":
+		digest := blake2b.Sum512(message)
+		message = digest[:]
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", sigRaw[:2])
+	}
+
+	if !ed25519.Verify(pk.key, message, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+
+	trustedComment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed trusted comment signature")
+	}
+
+	if !ed25519.Verify(pk.key, append(append([]byte{}, sig...), trustedComment...), globalSig) {
+		return fmt.Errorf("trusted comment signature verification failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// testSigner is an offline minisign keypair.
+type testSigner struct {
+	keyID [8]byte
+	priv  ed25519.PrivateKey
+	pub   ed25519.PublicKey
+}
+
+func newTestSigner(t *testing.T) *testSigner {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSigner{priv: priv, pub: pub}
+	if _, err := rand.Read(s.keyID[:]); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// publicKey returns the key as a minisign .pub file.
+func (s *testSigner) publicKey() string {
+	raw := append(append([]byte("Ed"), s.keyID[:]...), s.pub...)
+	return "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n"
+}
+
+// sign returns a minisign signature file for message; prehashed selects the
+// "ED" algorithm that signs a BLAKE2b-512 digest.
+func (s *testSigner) sign(message []byte, prehashed bool, trustedComment string) []byte {
+	alg, signed := "Ed", message
+	if prehashed {
+		digest := blake2b.Sum512(message)
+		alg, signed = "ED", digest[:]
+	}
+	sig := ed25519.Sign(s.priv, signed)
+	raw := append(append([]byte(alg), s.keyID[:]...), sig...)
+	global := ed25519.Sign(s.priv, append(append([]byte{}, sig...), trustedComment...))
+
+	return []byte("untrusted comment: signature\n" +
+		base64.StdEncoding.EncodeToString(raw) + "\n" +
+		"trusted comment: " + trustedComment + "\n" +
+		base64.StdEncoding.EncodeToString(global) + "\n")
+}
+
+func TestVerifySignature(t *testing.T) {
+	signer := newTestSigner(t)
+	checksums := []byte("abc  gha-freeze_1.2.0_linux_amd64.tar.gz\n")
+
+	for _, prehashed := range []bool{false, true} {
+		sig := signer.sign(checksums, prehashed, "gha-freeze 1.2.0")
+		if err := verifySignature(signer.publicKey(), checksums, sig); err != nil {
+			t.Errorf("prehashed=%v: %v", prehashed, err)
+		}
+	}
+
+	sig := signer.sign(checksums, true, "gha-freeze 1.2.0")
+	tests := []struct {
+		name      string
+		key       string
+		message   []byte
+		signature []byte
+		want      string
+	}{
+		{"tampered message", signer.publicKey(), []byte("def  gha-freeze_1.2.0_linux_amd64.tar.gz\n"), sig, "signature verification failed"},
+		{"other key", newTestSigner(t).publicKey(), checksums, sig, "different key"},
+		{"tampered trusted comment", signer.publicKey(), checksums, []byte(strings.Replace(string(sig), "1.2.0", "9.9.9", 1)), "trusted comment"},
+		{"malformed signature", signer.publicKey(), checksums, []byte("untrusted comment: x\nnot base64\n"), "malformed"},
+		{"invalid key", "not a key", checksums, sig, "invalid minisign public key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifySignature(tt.key, tt.message, tt.signature)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReleasePublicKey(t *testing.T) {
+	if _, err := parsePublicKey(releasePublicKey); err != nil {
+		t.Fatalf("embedded release key: %v", err)
+	}
+}
+
+// testdata/checksums.txt.minisig was made with the release key, so it only
+// verifies against the key that is embedded in the binary.
+func TestReleaseSignatureFixture(t *testing.T) {
+	checksums, err := os.ReadFile(filepath.Join("testdata", "checksums.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := os.ReadFile(filepath.Join("testdata", "checksums.txt"+signatureSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifySignature(releasePublicKey, checksums, sig); err != nil {
+		t.Fatalf("release fixture: %v", err)
+	}
+	if err := verifySignature(releasePublicKey, append(checksums, '\n'), sig); err == nil {
+		t.Error("release fixture verified with altered checksums")
+	}
+}
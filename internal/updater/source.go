@@ -0,0 +1,310 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v58/github"
+)
+
+const indexFile = "index.json"
+
+// Source says where releases are published. The zero value is the public
+// thinesjs/gha-freeze repository on github.com.
+//
+// URL selects a plain HTTPS mirror instead of GitHub. It must serve
+// index.json (see mirrorIndex) plus the archives, checksums.txt and its
+// signature.
+type Source struct {
+	Repo   string // owner/name on GitHub or GitHub Enterprise Server
+	APIURL string // API base URL, e.g. https://ghe.example.com/api/v3
+	URL    string // base URL of a release mirror
+}
+
+func (s Source) String() string {
+	if s.URL != "" {
+		return s.URL
+	}
+
+	repoName := s.Repo
+	if repoName == "" {
+		repoName = owner + "/" + repo
+	}
+	if s.APIURL != "" {
+		return strings.TrimSuffix(s.APIURL, "/") + " " + repoName
+	}
+	return repoName
+}
+
+func (s Source) Validate() error {
+	if s.URL != "" {
+		if s.Repo != "" || s.APIURL != "" {
+			return fmt.Errorf("update source: url cannot be combined with repo or api_url")
+		}
+		u, err := url.Parse(s.URL)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("update source: invalid url %q", s.URL)
+		}
+		if u.Scheme != "https" && !(u.Scheme == "http" && isLoopback(u.Hostname())) {
+			return fmt.Errorf("update source: url %q must use https", s.URL)
+		}
+		return nil
+	}
+
+	if s.Repo != "" {
+		parts := strings.Split(s.Repo, "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("update source: repo %q must be in owner/name form", s.Repo)
+		}
+	}
+	if s.APIURL != "" {
+		if u, err := url.Parse(s.APIURL); err != nil || u.Host == "" {
+			return fmt.Errorf("update source: invalid api_url %q", s.APIURL)
+		}
+	}
+	return nil
+}
+
+// ReleasesURL is where users can download releases by hand.
+func (s Source) ReleasesURL() string {
+	if s.URL != "" {
+		return s.URL
+	}
+
+	repoName := s.Repo
+	if repoName == "" {
+		repoName = owner + "/" + repo
+	}
+
+	web := "https://github.com"
+	if s.APIURL != "" {
+		if u, err := url.Parse(s.APIURL); err == nil {
+			web = u.Scheme + "://" + u.Host
+		}
+	}
+	return web + "/" + repoName + "/releases"
+}
+
+// Host is the API host that a token for s must have been issued for:
+// github.com, the host of APIURL, or empty for a mirror, which never gets
+// a token.
+func (s Source) Host() string {
+	if s.URL != "" {
+		return ""
+	}
+	if s.APIURL == "" {
+		return "github.com"
+	}
+	u, err := url.Parse(s.APIURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type release struct {
+	Tag        string         `json:"tag"`
+	Notes      string         `json:"notes,omitempty"`
+	Draft      bool           `json:"draft,omitempty"`
+	Prerelease bool           `json:"prerelease,omitempty"`
+	Assets     []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type releaseSource interface {
+	listReleases(ctx context.Context) ([]release, error)
+	releaseByTag(ctx context.Context, tag string) (*release, error)
+	// downloadToken is sent with asset downloads, if non-empty.
+	downloadToken() string
+}
+
+func (s Source) open(token string) (releaseSource, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	if s.URL != "" {
+		return &mirrorSource{base: strings.TrimSuffix(s.URL, "/") + "/"}, nil
+	}
+
+	owner, name := owner, repo
+	if s.Repo != "" {
+		owner, name, _ = strings.Cut(s.Repo, "/")
+	}
+
+	client := github.NewClient(httpClient)
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+	if s.APIURL != "" {
+		base := s.APIURL
+		if !strings.HasSuffix(base, "/") {
+			base += "/"
+		}
+		u, err := url.Parse(base)
+		if err != nil {
+			return nil, err
+		}
+		client.BaseURL = u
+	}
+
+	return &githubSource{
+		client: client,
+		owner:  owner,
+		repo:   name,
+		// Assets of private GitHub Enterprise repositories can only be
+		// fetched through the API with a token.
+		apiAssets: s.APIURL != "" && token != "",
+		token:     token,
+	}, nil
+}
+
+type githubSource struct {
+	client    *github.Client
+	owner     string
+	repo      string
+	apiAssets bool
+	token     string
+}
+
+func (g *githubSource) listReleases(ctx context.Context) ([]release, error) {
+	releases, _, err := g.client.Repositories.ListReleases(ctx, g.owner, g.repo, &github.ListOptions{PerPage: 50})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list releases: %w", err)
+	}
+
+	out := make([]release, 0, len(releases))
+	for _, r := range releases {
+		out = append(out, g.convert(r))
+	}
+	return out, nil
+}
+
+func (g *githubSource) releaseByTag(ctx context.Context, tag string) (*release, error) {
+	r, _, err := g.client.Repositories.GetReleaseByTag(ctx, g.owner, g.repo, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release %s: %w", tag, err)
+	}
+	rel := g.convert(r)
+	return &rel, nil
+}
+
+func (g *githubSource) downloadToken() string {
+	if g.apiAssets {
+		return g.token
+	}
+	return ""
+}
+
+func (g *githubSource) convert(r *github.RepositoryRelease) release {
+	rel := release{
+		Tag:        r.GetTagName(),
+		Notes:      r.GetBody(),
+		Draft:      r.GetDraft(),
+		Prerelease: r.GetPrerelease(),
+	}
+	for _, a := range r.Assets {
+		u := a.GetBrowserDownloadURL()
+		if g.apiAssets {
+			u = a.GetURL()
+		}
+		rel.Assets = append(rel.Assets, releaseAsset{Name: a.GetName(), URL: u})
+	}
+	return rel
+}
+
+// mirrorIndex is the document served at <base>/index.json:
+//
+//	{"releases": [{"tag": "v1.2.0", "prerelease": false, "notes": "...",
+//	  "assets": [{"name": "gha-freeze_1.2.0_linux_amd64.tar.gz"},
+//	             {"name": "checksums.txt"}, {"name": "checksums.txt.minisig"}]}]}
+//
+// An asset without a url is expected at <base>/<tag>/<name>; relative urls
+// are resolved against the index.
+type mirrorIndex struct {
+	Releases []release `json:"releases"`
+}
+
+type mirrorSource struct {
+	base string
+}
+
+func (m *mirrorSource) listReleases(ctx context.Context) ([]release, error) {
+	indexURL := m.base + indexFile
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release index: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch release index %s: %s", indexURL, resp.Status)
+	}
+
+	var index mirrorIndex
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("invalid release index %s: %w", indexURL, err)
+	}
+
+	base, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range index.Releases {
+		r := &index.Releases[i]
+		for j := range r.Assets {
+			a := &r.Assets[j]
+			ref := a.URL
+			if ref == "" {
+				ref = url.PathEscape(r.Tag) + "/" + url.PathEscape(a.Name)
+			}
+			u, err := url.Parse(ref)
+			if err != nil {
+				return nil, fmt.Errorf("invalid url for asset %s in release %s: %w", a.Name, r.Tag, err)
+			}
+			a.URL = base.ResolveReference(u).String()
+		}
+	}
+
+	return index.Releases, nil
+}
+
+func (m *mirrorSource) releaseByTag(ctx context.Context, tag string) (*release, error) {
+	releases, err := m.listReleases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range releases {
+		if releases[i].Tag == tag {
+			return &releases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("failed to get release %s: not found in %s", tag, m.base+indexFile)
+}
+
+func (m *mirrorSource) downloadToken() string { return "" }
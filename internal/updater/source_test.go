@@ -0,0 +1,99 @@
+package updater
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSourceHost(t *testing.T) {
+	tests := []struct {
+		source Source
+		want   string
+	}{
+		{Source{}, "github.com"},
+		{Source{Repo: "platform/gha-freeze"}, "github.com"},
+		{Source{APIURL: "https://ghe.example.com/api/v3"}, "ghe.example.com"},
+		{Source{URL: "https://artifacts.example.com/gha-freeze"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.source.Host(); got != tt.want {
+			t.Errorf("%v.Host() = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestMirrorSource(t *testing.T) {
+	name := assetName("1.2.0")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /gha-freeze/index.json", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(mirrorIndex{Releases: []release{
+			{Tag: "v1.2.0", Assets: []releaseAsset{
+				{Name: name},
+				{Name: checksumsFile, URL: "sums/checksums.txt"},
+				{Name: checksumsFile + signatureSuffix, URL: "https://elsewhere.example.com/checksums.txt.minisig"},
+			}},
+			{Tag: "v1.3.0-rc.1", Prerelease: true},
+		}})
+	})
+	var authorized []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			authorized = append(authorized, r.URL.Path)
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	source := Source{URL: srv.URL + "/gha-freeze"}
+	info, err := Check("v1.0.0", CheckOptions{Source: source, Token: "must-not-leak"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.LatestVersion != "1.2.0" {
+		t.Errorf("LatestVersion = %s, want the newest stable 1.2.0", info.LatestVersion)
+	}
+	want := map[string]string{
+		info.DownloadURL:  srv.URL + "/gha-freeze/v1.2.0/" + name,
+		info.ChecksumsURL: srv.URL + "/gha-freeze/sums/checksums.txt",
+		info.SignatureURL: "https://elsewhere.example.com/checksums.txt.minisig",
+	}
+	for got, w := range want {
+		if got != w {
+			t.Errorf("asset URL = %s, want %s", got, w)
+		}
+	}
+	if info.token != "" || len(authorized) > 0 {
+		t.Errorf("token sent to the mirror: download token %q, requests %v", info.token, authorized)
+	}
+}
+
+func TestEnterpriseSourceDownloadsThroughAPI(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		_ = json.NewEncoder(w).Encode([]map[string]any{{
+			"tag_name": "v1.2.0",
+			"assets": []map[string]any{{
+				"name":                 assetName("1.2.0"),
+				"url":                  "https://ghe.example.com/api/v3/repos/owner/name/releases/assets/1",
+				"browser_download_url": "https://ghe.example.com/owner/name/releases/download/v1.2.0/asset",
+			}},
+		}})
+	}))
+	defer srv.Close()
+
+	info, err := Check("v1.0.0", CheckOptions{Source: Source{Repo: "owner/name", APIURL: srv.URL + "/api/v3"}, Token: "ghe-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(auth) != 1 || !strings.HasSuffix(auth[0], "ghe-token") {
+		t.Errorf("Authorization = %v, want the token", auth)
+	}
+	if info.token != "ghe-token" || !strings.Contains(info.DownloadURL, "/releases/assets/") {
+		t.Errorf("private enterprise assets must be fetched through the API with the token: %+v", info)
+	}
+}
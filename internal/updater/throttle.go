@@ -0,0 +1,87 @@
+package updater
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const DefaultCheckInterval = 24 * time.Hour
+
+type CheckState struct {
+	CheckedAt     time.Time `json:"checked_at"`
+	Channel       Channel   `json:"channel"`
+	Source        string    `json:"source"`
+	LatestVersion string    `json:"latest_version"`
+}
+
+func LoadCheckState(path string) (CheckState, error) {
+	var state CheckState
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, err
+	}
+
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func SaveCheckState(path string, state CheckState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// CheckThrottled answers from the cached state when the last check for the
+// same channel and source happened within interval, and only hits the API otherwise.
+// Failed checks are recorded too, so an unreachable or rate-limited source is
+// not retried on every run; the last known version is kept.
+func CheckThrottled(currentVersion string, opts CheckOptions, statePath string, interval time.Duration) (*UpdateInfo, error) {
+	if interval <= 0 {
+		interval = DefaultCheckInterval
+	}
+
+	state, _ := LoadCheckState(statePath)
+	sameSource := state.Channel == opts.Channel && state.Source == opts.Source.String()
+	if sameSource && !state.CheckedAt.IsZero() && time.Since(state.CheckedAt) < interval {
+		current := strings.TrimPrefix(currentVersion, "v")
+		return &UpdateInfo{
+			CurrentVersion: current,
+			LatestVersion:  state.LatestVersion,
+			ReleasesURL:    opts.Source.ReleasesURL(),
+			Available:      state.LatestVersion != "" && compareVersions(current, state.LatestVersion) < 0,
+		}, nil
+	}
+
+	next := CheckState{
+		CheckedAt: time.Now(),
+		Channel:   opts.Channel,
+		Source:    opts.Source.String(),
+	}
+
+	info, err := Check(currentVersion, opts)
+	if err != nil {
+		if sameSource {
+			next.LatestVersion = state.LatestVersion
+		}
+		_ = SaveCheckState(statePath, next)
+		return nil, err
+	}
+
+	next.LatestVersion = info.LatestVersion
+	_ = SaveCheckState(statePath, next)
+
+	return info, nil
+}
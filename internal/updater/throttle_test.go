@@ -0,0 +1,74 @@
+package updater
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckThrottledRecordsFailures(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, `{"message":"unavailable"}`, http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	opts := CheckOptions{Source: Source{Repo: "owner/name", APIURL: srv.URL + "/api/v3"}}
+	statePath := filepath.Join(t.TempDir(), "update-check.json")
+
+	if _, err := CheckThrottled("v1.0.0", opts, statePath, time.Hour); err == nil {
+		t.Fatal("expected the failing check to return an error")
+	}
+	state, err := LoadCheckState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.CheckedAt.IsZero() {
+		t.Fatal("failed check was not recorded")
+	}
+
+	before := hits.Load()
+	info, err := CheckThrottled("v1.0.0", opts, statePath, time.Hour)
+	if err != nil {
+		t.Fatalf("throttled check: %v", err)
+	}
+	if info.Available {
+		t.Errorf("throttled check after a failure reported an update: %+v", info)
+	}
+	if hits.Load() != before {
+		t.Errorf("source queried again within the interval")
+	}
+}
+
+func TestCheckThrottledKeepsLastKnownVersion(t *testing.T) {
+	f := newFakeRelease(t, "v1.2.0")
+	opts := CheckOptions{Source: f.source()}
+	statePath := filepath.Join(t.TempDir(), "update-check.json")
+
+	if _, err := CheckThrottled("v1.0.0", opts, statePath, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// Expire the cached check and make the next one fail.
+	state, _ := LoadCheckState(statePath)
+	state.CheckedAt = time.Now().Add(-2 * time.Hour)
+	if err := SaveCheckState(statePath, state); err != nil {
+		t.Fatal(err)
+	}
+	f.server.Close()
+
+	if _, err := CheckThrottled("v1.0.0", opts, statePath, time.Hour); err == nil {
+		t.Fatal("expected an error from the closed server")
+	}
+	info, err := CheckThrottled("v1.0.0", opts, statePath, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Available || info.LatestVersion != "1.2.0" {
+		t.Errorf("CheckThrottled = %+v, want the last known 1.2.0", info)
+	}
+}
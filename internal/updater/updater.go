@@ -5,6 +5,8 @@ import (
 	"archive/zip"
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,51 +15,86 @@ import (
 	"runtime"
 	"strings"
 	"time"
-
-	"github.com/google/go-github/v58/github"
 )
 
 const (
-	owner = "thinesjs"
-	repo  = "gha-freeze"
+	owner         = "thinesjs"
+	repo          = "gha-freeze"
+	checksumsFile = "checksums.txt"
 )
 
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
 type UpdateInfo struct {
 	Available      bool
 	CurrentVersion string
 	LatestVersion  string
 	DownloadURL    string
+	AssetName      string
+	ChecksumsURL   string
+	SignatureURL   string
 	ReleaseNotes   string
+	ReleasesURL    string
+	Downgrade      bool
+
+	token string
 }
 
-func CheckForUpdate(currentVersion string) (*UpdateInfo, error) {
-	return CheckForUpdateWithToken(currentVersion, "")
+type Channel string
+
+const (
+	ChannelStable Channel = "stable"
+	ChannelBeta   Channel = "beta"
+)
+
+func ParseChannel(s string) (Channel, error) {
+	switch Channel(s) {
+	case "", ChannelStable:
+		return ChannelStable, nil
+	case ChannelBeta:
+		return ChannelBeta, nil
+	}
+	return "", fmt.Errorf("invalid update channel %q: expected \"stable\" or \"beta\"", s)
+}
+
+type CheckOptions struct {
+	Token   string
+	Channel Channel
+	Version string
+	Source  Source
 }
 
-func CheckForUpdateWithToken(currentVersion, token string) (*UpdateInfo, error) {
+func Check(currentVersion string, opts CheckOptions) (*UpdateInfo, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var client *github.Client
-	if token != "" {
-		client = github.NewClient(nil).WithAuthToken(token)
-	} else {
-		client = github.NewClient(nil)
+	src, err := opts.Source.open(opts.Token)
+	if err != nil {
+		return nil, err
 	}
 
-	release, _, err := client.Repositories.GetLatestRelease(ctx, owner, repo)
+	var release *release
+	if opts.Version != "" {
+		release, err = getReleaseByVersion(ctx, src, opts.Version)
+	} else {
+		release, err = getNewestRelease(ctx, src, opts.Channel)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest release: %w", err)
+		return nil, err
 	}
 
-	latestVersion := strings.TrimPrefix(release.GetTagName(), "v")
+	latestVersion := strings.TrimPrefix(release.Tag, "v")
 	currentVersionClean := strings.TrimPrefix(currentVersion, "v")
 
+	cmp := compareVersions(currentVersionClean, latestVersion)
 	info := &UpdateInfo{
 		CurrentVersion: currentVersionClean,
 		LatestVersion:  latestVersion,
-		ReleaseNotes:   release.GetBody(),
-		Available:      compareVersions(currentVersionClean, latestVersion) < 0,
+		ReleaseNotes:   release.Notes,
+		ReleasesURL:    opts.Source.ReleasesURL(),
+		Available:      cmp < 0 || (opts.Version != "" && cmp != 0),
+		Downgrade:      opts.Version != "" && cmp > 0,
+		token:          src.downloadToken(),
 	}
 
 	if info.Available {
@@ -66,53 +103,114 @@ func CheckForUpdateWithToken(currentVersion, token string) (*UpdateInfo, error)
 			info.DownloadURL = ""
 		} else {
 			info.DownloadURL = downloadURL
+			info.AssetName = assetName(latestVersion)
+			info.ChecksumsURL = findReleaseAsset(release, checksumsFile)
+			info.SignatureURL = findReleaseAsset(release, checksumsFile+signatureSuffix)
 		}
 	}
 
 	return info, nil
 }
 
-func compareVersions(current, latest string) int {
-	if current == latest {
-		return 0
+func getNewestRelease(ctx context.Context, src releaseSource, channel Channel) (*release, error) {
+	releases, err := src.listReleases(ctx)
+	if err != nil {
+		return nil, err
 	}
-	if current == "dev" || current == "" {
-		return -1
+
+	var newest *release
+	for i := range releases {
+		r := &releases[i]
+		if r.Draft {
+			continue
+		}
+		if channel != ChannelBeta && (r.Prerelease || isPrerelease(r.Tag)) {
+			continue
+		}
+		if _, ok := parseSemver(r.Tag); !ok {
+			continue
+		}
+		if newest == nil || compareVersions(newest.Tag, r.Tag) < 0 {
+			newest = r
+		}
 	}
-	if latest > current {
-		return -1
+
+	if newest == nil {
+		return nil, fmt.Errorf("no %s releases found", channelName(channel))
 	}
-	return 1
+	return newest, nil
 }
 
-func findAssetURL(release *github.RepositoryRelease) (string, error) {
+func getReleaseByVersion(ctx context.Context, src releaseSource, version string) (*release, error) {
+	tag := "v" + strings.TrimPrefix(version, "v")
+	return src.releaseByTag(ctx, tag)
+}
+
+func channelName(channel Channel) string {
+	if channel == "" {
+		return string(ChannelStable)
+	}
+	return string(channel)
+}
+
+func assetName(version string) string {
 	osName := runtime.GOOS
 	arch := runtime.GOARCH
 
-	version := strings.TrimPrefix(release.GetTagName(), "v")
-
-	assetName := fmt.Sprintf("gha-freeze_%s_%s_%s", version, osName, arch)
+	name := fmt.Sprintf("gha-freeze_%s_%s_%s", version, osName, arch)
 
 	if osName == "darwin" {
-		assetName = fmt.Sprintf("gha-freeze_%s_macOS_%s", version, arch)
+		name = fmt.Sprintf("gha-freeze_%s_macOS_%s", version, arch)
 	}
 
 	if osName == "windows" {
-		assetName += ".zip"
+		name += ".zip"
 	} else {
-		assetName += ".tar.gz"
+		name += ".tar.gz"
 	}
 
+	return name
+}
+
+func findAssetURL(release *release) (string, error) {
+	name := assetName(strings.TrimPrefix(release.Tag, "v"))
+
+	if u := findReleaseAsset(release, name); u != "" {
+		return u, nil
+	}
+
+	return "", fmt.Errorf("no compatible asset found for %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+func findReleaseAsset(release *release, name string) string {
 	for _, asset := range release.Assets {
-		if asset.GetName() == assetName {
-			return asset.GetBrowserDownloadURL(), nil
+		if asset.Name == name {
+			return asset.URL
 		}
 	}
-
-	return "", fmt.Errorf("no compatible asset found for %s/%s", osName, arch)
+	return ""
 }
 
-func DownloadAndInstall(downloadURL string) (err error) {
+func DownloadAndInstall(info *UpdateInfo) (err error) {
+	if info.ChecksumsURL == "" {
+		return fmt.Errorf("release does not publish %s; refusing to install an unverified binary", checksumsFile)
+	}
+	if info.SignatureURL == "" {
+		return fmt.Errorf("release does not publish a signature for %s; refusing to install an unverified binary", checksumsFile)
+	}
+
+	inst, err := DetectInstallation()
+	if err != nil {
+		return err
+	}
+	if inst.Managed() {
+		return fmt.Errorf("gha-freeze was installed with %s; upgrade with: %s", inst.Method, inst.UpgradeCommand())
+	}
+	if err := checkWritable(inst.Path); err != nil {
+		return err
+	}
+	currentExe := inst.Path
+
 	tempDir, err := os.MkdirTemp("", "gha-freeze-update-")
 	if err != nil {
 		return fmt.Errorf("failed to create temp directory: %w", err)
@@ -123,47 +221,135 @@ func DownloadAndInstall(downloadURL string) (err error) {
 		}
 	}()
 
-	archivePath := filepath.Join(tempDir, "download")
-	if err := downloadFile(archivePath, downloadURL); err != nil {
+	archivePath := filepath.Join(tempDir, filepath.Base(info.AssetName))
+	if err := downloadFile(archivePath, info.DownloadURL, info.token); err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}
 
+	checksumsPath := filepath.Join(tempDir, checksumsFile)
+	if err := downloadFile(checksumsPath, info.ChecksumsURL, info.token); err != nil {
+		return fmt.Errorf("failed to download %s: %w", checksumsFile, err)
+	}
+
+	signaturePath := checksumsPath + signatureSuffix
+	if err := downloadFile(signaturePath, info.SignatureURL, info.token); err != nil {
+		return fmt.Errorf("failed to download %s signature: %w", checksumsFile, err)
+	}
+
+	if err := verifyChecksumsSignature(checksumsPath, signaturePath); err != nil {
+		return err
+	}
+
+	if err := verifyChecksum(archivePath, checksumsPath, info.AssetName); err != nil {
+		return err
+	}
+
 	extractedPath, err := extractArchive(archivePath, tempDir)
 	if err != nil {
 		return fmt.Errorf("failed to extract archive: %w", err)
 	}
 
-	currentExe, err := os.Executable()
+	// Stage next to the executable so the final rename never crosses
+	// filesystems.
+	staged := currentExe + ".new"
+	if err := copyExecutable(extractedPath, staged); err != nil {
+		_ = os.Remove(staged)
+		return fmt.Errorf("failed to stage new executable: %w", err)
+	}
+
+	backupPath, err := swapExecutable(currentExe, staged)
 	if err != nil {
-		return fmt.Errorf("failed to get current executable path: %w", err)
+		_ = os.Remove(staged)
+		return err
 	}
 
-	currentExe, err = filepath.EvalSymlinks(currentExe)
+	if err := smokeTest(currentExe); err != nil {
+		if rerr := restoreExecutable(currentExe, backupPath); rerr != nil {
+			return fmt.Errorf("new version failed to start (%v) and restoring %s failed: %w", err, backupPath, rerr)
+		}
+		return fmt.Errorf("new version failed to start, rolled back to %s: %w", info.CurrentVersion, err)
+	}
+
+	// A failure to retain the old binary only disables rollback; the
+	// update itself has succeeded.
+	_ = retainPrevious(backupPath, info.CurrentVersion)
+	_ = os.Remove(backupPath)
+
+	return nil
+}
+
+func verifyChecksumsSignature(checksumsPath, signaturePath string) error {
+	checksums, err := os.ReadFile(checksumsPath)
 	if err != nil {
-		return fmt.Errorf("failed to resolve executable path: %w", err)
+		return fmt.Errorf("failed to read %s: %w", checksumsFile, err)
 	}
 
-	backupPath := currentExe + ".old"
-	if err := os.Rename(currentExe, backupPath); err != nil {
-		return fmt.Errorf("failed to backup current executable: %w", err)
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s signature: %w", checksumsFile, err)
 	}
 
-	if err := os.Rename(extractedPath, currentExe); err != nil {
-		_ = os.Rename(backupPath, currentExe)
-		return fmt.Errorf("failed to install new executable: %w", err)
+	if err := verifySignature(releasePublicKey, checksums, signature); err != nil {
+		return fmt.Errorf("could not verify the signature of %s: %w; aborting update", checksumsFile, err)
 	}
+	return nil
+}
 
-	if err := os.Chmod(currentExe, 0755); err != nil {
-		return fmt.Errorf("failed to set executable permissions: %w", err)
+func verifyChecksum(archivePath, checksumsPath, name string) error {
+	data, err := os.ReadFile(checksumsPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", checksumsFile, err)
 	}
 
-	_ = os.Remove(backupPath)
+	expected, err := lookupChecksum(data, name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to hash downloaded archive: %w", err)
+	}
+
+	actual := hex.EncodeToString(h.Sum(nil))
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s; the download may be corrupted or tampered with, aborting update", name, expected, actual)
+	}
 
 	return nil
 }
 
-func downloadFile(filepath string, url string) (err error) {
-	resp, err := http.Get(url)
+func lookupChecksum(checksums []byte, name string) (string, error) {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("%s has no entry for %s", checksumsFile, name)
+}
+
+func downloadFile(filepath string, url string, token string) (err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		// GitHub API asset URLs return the binary only when asked for it.
+		req.Header.Set("Accept", "application/octet-stream")
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
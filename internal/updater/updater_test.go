@@ -0,0 +1,159 @@
+package updater
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeRelease serves a GitHub Enterprise style API at /api/v3 with a single
+// release of owner/name whose assets are served from /download.
+type fakeRelease struct {
+	tag       string
+	archive   []byte
+	checksums string
+	server    *httptest.Server
+}
+
+func newFakeRelease(t *testing.T, tag string) *fakeRelease {
+	t.Helper()
+	f := &fakeRelease{tag: tag, archive: tarGz(t, "gha-freeze", "#!/bin/sh\necho new\n")}
+	name := assetName(strings.TrimPrefix(tag, "v"))
+	sum := sha256.Sum256(f.archive)
+	f.checksums = hex.EncodeToString(sum[:]) + "  " + name + "\n"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v3/repos/owner/name/releases", func(w http.ResponseWriter, r *http.Request) {
+		base := f.server.URL + "/download/"
+		_ = json.NewEncoder(w).Encode([]map[string]any{{
+			"tag_name": f.tag,
+			"body":     "notes",
+			"assets": []map[string]any{
+				{"name": name, "browser_download_url": base + name},
+				{"name": checksumsFile, "browser_download_url": base + checksumsFile},
+				{"name": checksumsFile + signatureSuffix, "browser_download_url": base + checksumsFile + signatureSuffix},
+			},
+		}})
+	})
+	mux.HandleFunc("GET /download/"+name, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(f.archive)
+	})
+	mux.HandleFunc("GET /download/"+checksumsFile, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, f.checksums)
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeRelease) source() Source {
+	return Source{Repo: "owner/name", APIURL: f.server.URL + "/api/v3"}
+}
+
+func tarGz(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// download fetches the archive and checksums of info and verifies them the
+// way DownloadAndInstall does before touching the executable.
+func download(t *testing.T, info *UpdateInfo) error {
+	t.Helper()
+	dir := t.TempDir()
+	archive := filepath.Join(dir, info.AssetName)
+	checksums := filepath.Join(dir, checksumsFile)
+	if err := downloadFile(archive, info.DownloadURL, info.token); err != nil {
+		t.Fatal(err)
+	}
+	if err := downloadFile(checksums, info.ChecksumsURL, info.token); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyChecksum(archive, checksums, info.AssetName); err != nil {
+		return err
+	}
+	if _, err := extractArchive(archive, dir); err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	return nil
+}
+
+func TestCheckFindsReleaseAssets(t *testing.T) {
+	f := newFakeRelease(t, "v1.2.0")
+
+	info, err := Check("v1.1.0", CheckOptions{Source: f.source()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Available || info.LatestVersion != "1.2.0" {
+		t.Fatalf("Check = %+v, want 1.2.0 available", info)
+	}
+	if info.ChecksumsURL == "" || info.SignatureURL == "" || info.DownloadURL == "" {
+		t.Fatalf("release assets not found: %+v", info)
+	}
+	if err := download(t, info); err != nil {
+		t.Errorf("verifying an intact download: %v", err)
+	}
+
+	if info, err := Check("v1.2.0", CheckOptions{Source: f.source()}); err != nil || info.Available {
+		t.Errorf("Check(current) = %+v, %v; want no update", info, err)
+	}
+}
+
+func TestChecksumMismatchAborts(t *testing.T) {
+	f := newFakeRelease(t, "v1.2.0")
+	info, err := Check("v1.1.0", CheckOptions{Source: f.source()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.archive = tarGz(t, "gha-freeze", "#!/bin/sh\necho tampered\n")
+	err = download(t, info)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("tampered archive: error = %v, want a checksum mismatch", err)
+	}
+
+	f.checksums = strings.Repeat("0", 64) + "  gha-freeze_1.2.0_other_arch.tar.gz\n"
+	err = download(t, info)
+	if err == nil || !strings.Contains(err.Error(), "has no entry for") {
+		t.Errorf("checksums without the asset: error = %v, want a missing entry", err)
+	}
+}
+
+func TestLookupChecksum(t *testing.T) {
+	checksums := []byte("abc  gha-freeze_1.0.0_linux_amd64.tar.gz\ndef *gha-freeze_1.0.0_windows_amd64.zip\n\nmalformed\n")
+	for name, want := range map[string]string{
+		"gha-freeze_1.0.0_linux_amd64.tar.gz": "abc",
+		"gha-freeze_1.0.0_windows_amd64.zip":  "def",
+	} {
+		if got, err := lookupChecksum(checksums, name); err != nil || got != want {
+			t.Errorf("lookupChecksum(%s) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := lookupChecksum(checksums, "malformed"); err == nil {
+		t.Error("expected an error for a missing entry")
+	}
+}
@@ -7,37 +7,65 @@ import (
 	"strings"
 )
 
-func FindWorkflowFiles() ([]string, error) {
-	workflowDir := ".github/workflows"
+// DefaultDirs are the workflow directories used by GitHub Actions, Gitea
+// Actions and Forgejo Actions.
+var DefaultDirs = []string{".github/workflows", ".gitea/workflows", ".forgejo/workflows"}
 
-	if _, err := os.Stat(workflowDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("workflows directory not found: %s", workflowDir)
+// DetectDirs returns the DefaultDirs that exist in the repository at root.
+func DetectDirs(root string) []string {
+	var dirs []string
+	for _, dir := range DefaultDirs {
+		if info, err := os.Stat(filepath.Join(root, dir)); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
 	}
+	return dirs
+}
 
-	var workflows []string
+func FindWorkflowFiles(root string) ([]string, error) {
+	return FindWorkflowFilesIn(root, DetectDirs(root))
+}
 
-	err := filepath.Walk(workflowDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// FindWorkflowFilesIn returns the workflow files under dirs, which are
+// relative to root. The returned paths are relative to root as well.
+func FindWorkflowFilesIn(root string, dirs []string) ([]string, error) {
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no workflows directory found (looked for %s)", strings.Join(DefaultDirs, ", "))
+	}
+
+	var workflows []string
+	for _, workflowDir := range dirs {
+		if _, err := os.Stat(filepath.Join(root, workflowDir)); os.IsNotExist(err) {
+			return nil, fmt.Errorf("workflows directory not found: %s", workflowDir)
 		}
 
-		if info.IsDir() {
-			if strings.HasPrefix(info.Name(), ".backup-") {
-				return filepath.SkipDir
+		err := filepath.Walk(filepath.Join(root, workflowDir), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
 			}
-			return nil
-		}
 
-		ext := filepath.Ext(path)
-		if ext == ".yml" || ext == ".yaml" {
-			workflows = append(workflows, path)
-		}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), ".backup-") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			ext := filepath.Ext(path)
+			if ext == ".yml" || ext == ".yaml" {
+				rel, err := filepath.Rel(root, path)
+				if err != nil {
+					return err
+				}
+				workflows = append(workflows, rel)
+			}
 
-		return nil
-	})
+			return nil
+		})
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan workflows directory: %w", err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan workflows directory: %w", err)
+		}
 	}
 
 	return workflows, nil
@@ -0,0 +1,48 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFindWorkflowFilesRelativeToRoot(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{
+		".github/workflows/ci.yml",
+		".github/workflows/nested/release.yaml",
+		".github/workflows/.backup-20250101-120000/ci.yml",
+		".github/workflows/notes.md",
+		".forgejo/workflows/deploy.yml",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if dirs := DetectDirs(root); !slices.Equal(dirs, []string{".github/workflows", ".forgejo/workflows"}) {
+		t.Errorf("DetectDirs = %v", dirs)
+	}
+
+	files, err := FindWorkflowFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(".github", "workflows", "ci.yml"),
+		filepath.Join(".github", "workflows", "nested", "release.yaml"),
+		filepath.Join(".forgejo", "workflows", "deploy.yml"),
+	}
+	if !slices.Equal(files, want) {
+		t.Errorf("FindWorkflowFiles = %v, want %v", files, want)
+	}
+
+	if _, err := FindWorkflowFilesIn(root, []string{".gitea/workflows"}); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
@@ -3,6 +3,7 @@ package workflow
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -22,49 +23,64 @@ type ActionReference struct {
 var actionRegex = regexp.MustCompile(`^([^/]+)/([^@]+)@(.+)$`)
 var shaRegex = regexp.MustCompile(`^[a-f0-9]{40}$`)
 
-func ParseWorkflowFile(filePath string) ([]ActionReference, error) {
-	content, err := os.ReadFile(filePath)
+// ParseWorkflowFile parses filePath, which is relative to the repository at
+// root.
+func ParseWorkflowFile(root, filePath string) ([]ActionReference, error) {
+	content, err := os.ReadFile(filepath.Join(root, filePath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var workflow map[string]interface{}
-	if err := yaml.Unmarshal(content, &workflow); err != nil {
+	return ParseWorkflowContent(content, filePath)
+}
+
+// ParseWorkflowContent parses workflow YAML that has already been read;
+// filePath is only recorded on the returned references. Each reference
+// carries the line of its own uses: value, so repeated steps can be told
+// apart.
+func ParseWorkflowContent(content []byte, filePath string) ([]ActionReference, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
 		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
 
-	var actions []ActionReference
-	lines := strings.Split(string(content), "\n")
+	root := resolveAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse YAML: top level is not a mapping")
+	}
 
-	jobs, ok := workflow["jobs"].(map[string]interface{})
-	if !ok {
+	var actions []ActionReference
+	jobs := mappingValue(root, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
 		return actions, nil
 	}
 
-	for _, job := range jobs {
-		jobMap, ok := job.(map[string]interface{})
-		if !ok {
+	for i := 1; i < len(jobs.Content); i += 2 {
+		job := resolveAlias(jobs.Content[i])
+		if job.Kind != yaml.MappingNode {
 			continue
 		}
 
-		steps, ok := jobMap["steps"].([]interface{})
-		if !ok {
+		steps := mappingValue(job, "steps")
+		if steps == nil || steps.Kind != yaml.SequenceNode {
 			continue
 		}
 
-		for _, step := range steps {
-			stepMap, ok := step.(map[string]interface{})
-			if !ok {
+		for _, step := range steps.Content {
+			step = resolveAlias(step)
+			if step.Kind != yaml.MappingNode {
 				continue
 			}
 
-			uses, ok := stepMap["uses"].(string)
-			if !ok {
+			uses := mappingValue(step, "uses")
+			if uses == nil || uses.Kind != yaml.ScalarNode {
 				continue
 			}
 
-			lineNum := findLineNumber(lines, uses)
-			action := parseActionString(uses, filePath, lineNum)
+			action := parseActionString(uses.Value, filePath, uses.Line)
 			if action != nil {
 				actions = append(actions, *action)
 			}
@@ -74,9 +90,34 @@ func ParseWorkflowFile(filePath string) ([]ActionReference, error) {
 	return actions, nil
 }
 
+// mappingValue returns the value for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveAlias(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
 func parseActionString(uses, filePath string, lineNum int) *ActionReference {
 	uses = strings.TrimSpace(uses)
 
+	// Forgejo lets uses: name an action on another host by URL, such as
+	// https://code.forgejo.org/actions/checkout@v4, which cannot be resolved
+	// against the configured host, and docker:// images are not actions at
+	// all. Both are left alone.
+	if strings.Contains(uses, "://") {
+		return nil
+	}
+
 	matches := actionRegex.FindStringSubmatch(uses)
 	if matches == nil {
 		return nil
@@ -95,12 +136,3 @@ func parseActionString(uses, filePath string, lineNum int) *ActionReference {
 		IsPinned: isPinned,
 	}
 }
-
-func findLineNumber(lines []string, searchText string) int {
-	for i, line := range lines {
-		if strings.Contains(line, "uses:") && strings.Contains(line, searchText) {
-			return i + 1
-		}
-	}
-	return 0
-}
@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"testing"
+)
+
+const duplicateWorkflow = `name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: make
+      - uses: actions/checkout@v4
+        with:
+          path: other
+  test:
+    steps:
+      - name: Setup
+        uses: "actions/setup-go@v5"
+      - uses: actions/checkout@692973e3d937129bcbf40652eb9f2f61becf3332
+      - uses: ./local-action
+`
+
+func TestParseWorkflowContentLines(t *testing.T) {
+	actions, err := ParseWorkflowContent([]byte(duplicateWorkflow), "ci.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		uses   string
+		line   int
+		pinned bool
+	}{
+		{"actions/checkout@v4", 7, false},
+		{"actions/checkout@v4", 9, false},
+		{"actions/setup-go@v5", 15, false},
+		{"actions/checkout@692973e3d937129bcbf40652eb9f2f61becf3332", 16, true},
+	}
+	if len(actions) != len(want) {
+		t.Fatalf("got %d actions, want %d: %+v", len(actions), len(want), actions)
+	}
+	for i, w := range want {
+		a := actions[i]
+		if a.FullUses != w.uses || a.Line != w.line || a.IsPinned != w.pinned || a.FilePath != "ci.yml" {
+			t.Errorf("action %d = %s line %d pinned %v, want %s line %d pinned %v",
+				i, a.FullUses, a.Line, a.IsPinned, w.uses, w.line, w.pinned)
+		}
+	}
+}
+
+func TestParseWorkflowContentInvalid(t *testing.T) {
+	if _, err := ParseWorkflowContent([]byte("jobs: [unclosed"), "bad.yml"); err == nil {
+		t.Error("expected an error for malformed YAML")
+	}
+	if _, err := ParseWorkflowContent([]byte("- a\n- b\n"), "list.yml"); err == nil {
+		t.Error("expected an error for a top-level list")
+	}
+	if actions, err := ParseWorkflowContent(nil, "empty.yml"); err != nil || len(actions) != 0 {
+		t.Errorf("empty file: %v, %v", actions, err)
+	}
+}
+
+func TestParseWorkflowContentSkipsURLs(t *testing.T) {
+	content := `jobs:
+  build:
+    steps:
+      - uses: https://code.forgejo.org/actions/checkout@v4
+      - uses: docker://alpine@sha256:4bcff63911fcb4448bd4fdacec207030997caf25e9bea4045fa6c8c44de311d1
+      - uses: actions/setup-go@v5
+`
+	actions, err := ParseWorkflowContent([]byte(content), "ci.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 1 || actions[0].Owner != "actions" || actions[0].Repo != "setup-go" {
+		t.Errorf("got %+v, want only actions/setup-go", actions)
+	}
+}
@@ -1,8 +1,11 @@
 package workflow
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -12,28 +15,96 @@ type Replacement struct {
 	Version string
 }
 
-func ReplaceActionsInFile(filePath string, replacements []Replacement) error {
-	content, err := os.ReadFile(filePath)
+type FileChange struct {
+	Path string
+	Old  []byte
+	New  []byte
+}
+
+// ReplaceActionsInFile rewrites filePath, which is relative to the
+// repository at root.
+func ReplaceActionsInFile(root, filePath string, replacements []Replacement) error {
+	path := filepath.Join(root, filePath)
+	content, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	newContent := ApplyReplacements(content, replacements)
+	if err := os.WriteFile(path, newContent, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyReplacements rewrites the uses: value on each replacement's
+// Action.Line only, so other steps that use the same action are left as
+// they are. Replacements whose line no longer holds the action are skipped.
+func ApplyReplacements(content []byte, replacements []Replacement) []byte {
 	lines := strings.Split(string(content), "\n")
 
 	for _, repl := range replacements {
-		for i, line := range lines {
-			if strings.Contains(line, repl.Action.FullUses) {
-				oldUses := fmt.Sprintf("%s/%s@%s", repl.Action.Owner, repl.Action.Repo, repl.Action.Ref)
-				newUses := fmt.Sprintf("%s/%s@%s # %s", repl.Action.Owner, repl.Action.Repo, repl.SHA, repl.Version)
-				lines[i] = strings.Replace(line, oldUses, newUses, 1)
-			}
+		i := repl.Action.Line - 1
+		if i < 0 || i >= len(lines) || !strings.Contains(lines[i], repl.Action.FullUses) {
+			continue
 		}
+		newUses := fmt.Sprintf("%s/%s@%s # %s", repl.Action.Owner, repl.Action.Repo, repl.SHA, repl.Version)
+		lines[i] = strings.Replace(lines[i], repl.Action.FullUses, newUses, 1)
 	}
 
-	newContent := strings.Join(lines, "\n")
-	if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
-	}
+	return []byte(strings.Join(lines, "\n"))
+}
 
+// WriteChanges writes each change's new content under root. If a write
+// fails, the files already written are put back.
+func WriteChanges(root string, changes []FileChange) error {
+	for i, c := range changes {
+		if err := os.WriteFile(filepath.Join(root, c.Path), c.New, 0644); err != nil {
+			_ = RevertChanges(root, changes[:i])
+			return fmt.Errorf("failed to write %s: %w", c.Path, err)
+		}
+	}
 	return nil
 }
+
+// RevertChanges restores each change's old content under root.
+func RevertChanges(root string, changes []FileChange) error {
+	var errs []error
+	for _, c := range changes {
+		if err := os.WriteFile(filepath.Join(root, c.Path), c.Old, 0644); err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", c.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PlanChanges reads the files the replacements refer to from the
+// repository at root and returns their new content. Paths stay relative.
+func PlanChanges(root string, replacements []Replacement) ([]FileChange, error) {
+	fileReplacements := make(map[string][]Replacement)
+	for _, repl := range replacements {
+		fileReplacements[repl.Action.FilePath] = append(fileReplacements[repl.Action.FilePath], repl)
+	}
+
+	files := make([]string, 0, len(fileReplacements))
+	for file := range fileReplacements {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var changes []FileChange
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(root, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		newContent := ApplyReplacements(content, fileReplacements[file])
+		if string(newContent) != string(content) {
+			changes = append(changes, FileChange{Path: file, Old: content, New: newContent})
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,36 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+)
+
+const sha1 = "1111111111111111111111111111111111111111"
+
+func TestApplyReplacementsOnlyTouchesItsLine(t *testing.T) {
+	actions, err := ParseWorkflowContent([]byte(duplicateWorkflow), "ci.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pin only the second of the two identical checkout steps.
+	got := string(ApplyReplacements([]byte(duplicateWorkflow), []Replacement{
+		{Action: actions[1], SHA: sha1, Version: "v4"},
+	}))
+
+	lines := strings.Split(got, "\n")
+	if lines[6] != "      - uses: actions/checkout@v4" {
+		t.Errorf("line 7 changed: %q", lines[6])
+	}
+	if lines[8] != "      - uses: actions/checkout@"+sha1+" # v4" {
+		t.Errorf("line 9 = %q, want it pinned", lines[8])
+	}
+}
+
+func TestApplyReplacementsSkipsStaleLine(t *testing.T) {
+	action := ActionReference{Owner: "actions", Repo: "checkout", Ref: "v4", Line: 8, FullUses: "actions/checkout@v4"}
+	got := ApplyReplacements([]byte(duplicateWorkflow), []Replacement{{Action: action, SHA: sha1, Version: "v4"}})
+	if string(got) != duplicateWorkflow {
+		t.Errorf("content changed although line 8 has no checkout step")
+	}
+}
@@ -0,0 +1,39 @@
+package freeze
+
+import "sync"
+
+// Cache stores successful resolutions keyed by "owner/repo@ref".
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	Get(action string) (sha, version string, ok bool)
+	Set(action, sha, version string)
+}
+
+type cacheEntry struct {
+	sha     string
+	version string
+}
+
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: make(map[string]cacheEntry)}
+}
+
+func (c *memoryCache) Get(action string) (string, string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	e, ok := c.entries[action]
+	return e.sha, e.version, ok
+}
+
+func (c *memoryCache) Set(action, sha, version string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[action] = cacheEntry{sha: sha, version: version}
+}
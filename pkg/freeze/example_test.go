@@ -0,0 +1,73 @@
+package freeze_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing/fstest"
+
+	"github.com/thinesjs/gha-freeze/pkg/freeze"
+)
+
+func Example() {
+	// A stand-in for the GitHub API that knows one tag.
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/repos/actions/checkout/git/ref/tags/v4" {
+			fmt.Fprint(w, `{"object":{"sha":"11bd71901bbe5b1630ceea73d27597364c9af683"}}`)
+			return
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer api.Close()
+
+	repo := fstest.MapFS{
+		".github/workflows/ci.yml": {Data: []byte(`on: push
+jobs:
+  build:
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/does-not-exist@v1
+`)},
+	}
+
+	ctx := context.Background()
+	f := freeze.New(freeze.WithHost(api.URL + "/api/v3/"))
+
+	refs, err := f.Scan(ctx, repo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	res, err := f.Resolve(ctx, refs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range res {
+		if r.Reason == freeze.ReasonNotFound || r.Reason == freeze.ReasonPrivate {
+			fmt.Printf("skipping %s: %s\n", r.Reference.Action(), r.Reason)
+		}
+	}
+
+	edits, err := f.Plan(ctx, repo, res)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = f.Apply(ctx, freeze.WriterFunc(func(path string, data []byte) error {
+		fmt.Printf("%s:\n%s", path, data)
+		return nil
+	}), edits)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Output:
+	// skipping actions/does-not-exist@v1: private or inaccessible repository
+	// .github/workflows/ci.yml:
+	// on: push
+	// jobs:
+	//   build:
+	//     steps:
+	//       - uses: actions/checkout@11bd71901bbe5b1630ceea73d27597364c9af683 # v4
+	//       - uses: actions/does-not-exist@v1
+}
@@ -0,0 +1,383 @@
+// Package freeze finds GitHub Actions references in workflow files, resolves
+// them to commit SHAs and rewrites the workflows to use the pinned SHAs.
+//
+// It works on an fs.FS and a Writer rather than the working directory, so
+// it can run against a checkout, an in-memory filesystem or content fetched
+// from an API:
+//
+//	f := freeze.New(freeze.WithToken(token))
+//	refs, err := f.Scan(ctx, os.DirFS(repo))
+//	res, err := f.Resolve(ctx, refs)
+//	edits, err := f.Plan(ctx, os.DirFS(repo), res)
+//	err = f.Apply(ctx, freeze.DirWriter(repo), edits)
+package freeze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thinesjs/gha-freeze/internal/gitea"
+	"github.com/thinesjs/gha-freeze/internal/github"
+	"github.com/thinesjs/gha-freeze/internal/lsremote"
+	"github.com/thinesjs/gha-freeze/internal/workflow"
+)
+
+// WorkflowDir is where GitHub Actions workflows live.
+const WorkflowDir = ".github/workflows"
+
+// DefaultWorkflowDirs are the directories Scan looks in unless
+// WithWorkflowDirs says otherwise: GitHub, Gitea and Forgejo Actions.
+var DefaultWorkflowDirs = []string{WorkflowDir, ".gitea/workflows", ".forgejo/workflows"}
+
+// Reference is a "uses: owner/repo@ref" step found in a workflow file.
+type Reference struct {
+	Path   string // slash-separated path within the scanned filesystem
+	Line   int
+	Owner  string
+	Repo   string // may include a subdirectory, e.g. "codeql-action/init"
+	Ref    string
+	Uses   string // the uses: value as written
+	Pinned bool   // Ref is already a full commit SHA
+}
+
+// Action returns "owner/repo@ref".
+func (r Reference) Action() string {
+	return r.Owner + "/" + r.Repo + "@" + r.Ref
+}
+
+// Resolution is the outcome of resolving one Reference. Err is set when the
+// reference could not be resolved; Reason then says why.
+type Resolution struct {
+	Reference Reference
+	SHA       string
+	Version   string
+	Err       error
+	Reason    Reason
+}
+
+// Reason classifies why a reference could not be resolved. Its value is a
+// short human-readable description.
+type Reason string
+
+const (
+	ReasonNotFound   Reason = github.FailureNotFound   // the ref does not exist
+	ReasonPrivate    Reason = github.FailurePrivate    // the repository is missing or hidden from the token
+	ReasonPermission Reason = github.FailurePermission // the token was rejected
+	ReasonNetwork    Reason = github.FailureNetwork
+	ReasonRateLimit  Reason = github.FailureRateLimit
+	ReasonUnknown    Reason = github.FailureUnknown
+)
+
+// Edit is the new content for one workflow file.
+type Edit struct {
+	Path string
+	Old  []byte
+	New  []byte
+}
+
+// Freezer scans, resolves and pins action references. It is safe for
+// concurrent use.
+type Freezer struct {
+	client       *github.Client
+	cache        Cache
+	concurrency  int
+	workflowDirs []string
+}
+
+func New(opts ...Option) *Freezer {
+	o := options{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var clientOpts []github.Option
+	if o.httpClient != nil {
+		clientOpts = append(clientOpts, github.WithHTTPClient(o.httpClient))
+	}
+	if o.baseURL != "" {
+		clientOpts = append(clientOpts, github.WithBaseURL(o.baseURL))
+	}
+	var giteaResolver github.Resolver
+	if o.giteaHost != "" {
+		giteaResolver = gitea.NewResolver(o.giteaHost, o.token, o.httpClient)
+		clientOpts = append(clientOpts, github.WithResolver(giteaResolver))
+	}
+
+	if o.cache == nil {
+		o.cache = NewMemoryCache()
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+
+	client := github.NewClient(o.token, clientOpts...)
+	if o.gitRefs {
+		fallback := giteaResolver
+		if fallback == nil {
+			fallback = github.ResolverFunc(client.ResolveREST)
+		}
+		client.SetResolver(lsremote.NewResolver(gitBaseURL(o), fallback, nil))
+	}
+
+	return &Freezer{
+		client:       client,
+		cache:        o.cache,
+		concurrency:  o.concurrency,
+		workflowDirs: o.workflowDirs,
+	}
+}
+
+// Scan returns the action references in every workflow file under the
+// workflow directories in fsys, ordered by file and line. Without
+// WithWorkflowDirs, those of DefaultWorkflowDirs that exist are scanned.
+func (f *Freezer) Scan(ctx context.Context, fsys fs.FS) ([]Reference, error) {
+	dirs := f.workflowDirs
+	if len(dirs) == 0 {
+		for _, dir := range DefaultWorkflowDirs {
+			if info, err := fs.Stat(fsys, dir); err == nil && info.IsDir() {
+				dirs = append(dirs, dir)
+			}
+		}
+		if len(dirs) == 0 {
+			return nil, fmt.Errorf("no workflows directory found (looked for %s): %w",
+				strings.Join(DefaultWorkflowDirs, ", "), fs.ErrNotExist)
+		}
+	}
+
+	var refs []Reference
+	for _, dir := range dirs {
+		if _, err := fs.Stat(fsys, dir); err != nil {
+			return nil, fmt.Errorf("workflows directory not found: %s: %w", dir, err)
+		}
+		if err := scanDir(ctx, fsys, dir, &refs); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		if refs[i].Path != refs[j].Path {
+			return refs[i].Path < refs[j].Path
+		}
+		return refs[i].Line < refs[j].Line
+	})
+	return refs, nil
+}
+
+func scanDir(ctx context.Context, fsys fs.FS, dir string, refs *[]Reference) error {
+	return fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".backup-") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if ext := path.Ext(p); ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+
+		actions, err := workflow.ParseWorkflowContent(content, p)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		for _, a := range actions {
+			*refs = append(*refs, fromAction(a))
+		}
+		return nil
+	})
+}
+
+// Resolve looks up the commit SHA for each unpinned reference, returning one
+// Resolution per input in the same order. Failures are reported per
+// reference; the error is only non-nil if ctx is done. Pinned references are
+// returned with their existing SHA and are left alone by Plan.
+func (f *Freezer) Resolve(ctx context.Context, refs []Reference) ([]Resolution, error) {
+	type result struct {
+		sha, version string
+		err          error
+	}
+
+	results := make(map[string]*result)
+	var keys []string
+	for _, r := range refs {
+		if r.Pinned {
+			continue
+		}
+		key := r.Action()
+		if _, ok := results[key]; ok {
+			continue
+		}
+		results[key] = &result{}
+		keys = append(keys, key)
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < f.concurrency && w < len(keys); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				key := keys[i]
+				res := results[key]
+				if sha, version, ok := f.cache.Get(key); ok {
+					res.sha, res.version = sha, version
+					continue
+				}
+
+				owner, repo, ref := splitAction(key)
+				resolved := f.client.ResolveActionContext(ctx, owner, repo, ref)
+				res.sha, res.version, res.err = resolved.SHA, resolved.Version, resolved.Error
+				if res.err == nil {
+					f.cache.Set(key, res.sha, res.version)
+				}
+			}
+		}()
+	}
+
+	for i := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]Resolution, len(refs))
+	for i, r := range refs {
+		out[i] = Resolution{Reference: r}
+		if r.Pinned {
+			out[i].SHA = r.Ref
+			continue
+		}
+
+		res := results[r.Action()]
+		if res.err != nil {
+			out[i].Err = res.err
+			out[i].Reason = Reason(github.ClassifyResolveError(res.err))
+			continue
+		}
+		out[i].SHA = res.sha
+		out[i].Version = res.version
+	}
+	return out, nil
+}
+
+// Plan computes the edits that pin every successful resolution, reading
+// the current content from fsys. Files that would not change are omitted.
+func (f *Freezer) Plan(ctx context.Context, fsys fs.FS, resolutions []Resolution) ([]Edit, error) {
+	byFile := make(map[string][]workflow.Replacement)
+	for _, r := range resolutions {
+		if r.Err != nil || r.Reference.Pinned || r.SHA == "" {
+			continue
+		}
+		byFile[r.Reference.Path] = append(byFile[r.Reference.Path], workflow.Replacement{
+			Action:  toAction(r.Reference),
+			SHA:     r.SHA,
+			Version: r.Version,
+		})
+	}
+
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var edits []Edit
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		updated := workflow.ApplyReplacements(content, byFile[file])
+		if string(updated) != string(content) {
+			edits = append(edits, Edit{Path: file, Old: content, New: updated})
+		}
+	}
+	return edits, nil
+}
+
+// Apply writes each edit's new content through w.
+func (f *Freezer) Apply(ctx context.Context, w Writer, edits []Edit) error {
+	if w == nil {
+		return errNoWriter
+	}
+
+	for _, e := range edits {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fs.ValidPath(e.Path) {
+			return fmt.Errorf("invalid path %q", e.Path)
+		}
+		if err := w.WriteFile(e.Path, e.New); err != nil {
+			return fmt.Errorf("failed to write %s: %w", e.Path, err)
+		}
+	}
+	return nil
+}
+
+func fromAction(a workflow.ActionReference) Reference {
+	return Reference{
+		Path:   a.FilePath,
+		Line:   a.Line,
+		Owner:  a.Owner,
+		Repo:   a.Repo,
+		Ref:    a.Ref,
+		Uses:   a.FullUses,
+		Pinned: a.IsPinned,
+	}
+}
+
+func toAction(r Reference) workflow.ActionReference {
+	return workflow.ActionReference{
+		Owner:    r.Owner,
+		Repo:     r.Repo,
+		Ref:      r.Ref,
+		Line:     r.Line,
+		FilePath: r.Path,
+		FullUses: r.Uses,
+		IsPinned: r.Pinned,
+	}
+}
+
+// splitAction splits "owner/repo[/path]@ref" into the repository to query
+// and the ref; a subdirectory such as "codeql-action/init" lives in the
+// "codeql-action" repository.
+func splitAction(action string) (owner, repo, ref string) {
+	uses, ref, _ := strings.Cut(action, "@")
+	owner, repo, _ = strings.Cut(uses, "/")
+	repo, _, _ = strings.Cut(repo, "/")
+	return owner, repo, ref
+}
+
+var errNoWriter = errors.New("freeze: nil Writer")
@@ -0,0 +1,271 @@
+package freeze
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+const (
+	checkoutSHA = "11bd71901bbe5b1630ceea73d27597364c9af683"
+	setupGoSHA  = "0aaccfd150d50ccaeb58ebd88d36e91967a5f35b"
+)
+
+var fixture = fstest.MapFS{
+	".github/workflows/ci.yml": {Data: []byte(`on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@main
+      - uses: actions/checkout@v4
+      - uses: ./local-action
+      - uses: actions/cache@` + checkoutSHA + ` # v4
+`)},
+	".github/workflows/lint.yaml": {Data: []byte(`on: push
+jobs:
+  lint:
+    steps:
+      - uses: acme/missing@v1
+      - uses: acme/hidden@v1
+`)},
+	".github/workflows/README.md":              {Data: []byte("uses: actions/checkout@v4\n")},
+	".github/workflows/.backup-1/ci.yml":       {Data: []byte("jobs:\n  a:\n    steps:\n      - uses: old/backup@v1\n")},
+	".forgejo/workflows/deploy.yml":            {Data: []byte("jobs:\n  deploy:\n    steps:\n      - uses: actions/checkout@v4\n")},
+	"src/.github/workflows/ignored.yml":        {Data: []byte("jobs:\n  a:\n    steps:\n      - uses: nested/ignored@v1\n")},
+	".gitea/not-workflows/also-ignored.yml":    {Data: []byte("jobs:\n  a:\n    steps:\n      - uses: also/ignored@v1\n")},
+	".github/workflows/invalid.yml.disabled":   {Data: []byte("{")},
+	".github/workflows/sub/reusable.yml":       {Data: []byte("jobs:\n  a:\n    steps:\n      - uses: actions/setup-go@main\n")},
+	".github/workflows/sub/.backup-2/old.yaml": {Data: []byte("{")},
+}
+
+// fakeAPI answers the REST calls the resolver makes: tag refs, commits and
+// repositories. It counts requests per path.
+type fakeAPI struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func newFakeAPI(t *testing.T) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+	api := &fakeAPI{calls: make(map[string]int)}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+	a.mu.Lock()
+	a.calls[path]++
+	a.mu.Unlock()
+
+	notFound := func() { http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound) }
+	switch path {
+	case "/repos/actions/checkout/git/ref/tags/v4":
+		fmt.Fprintf(w, `{"ref":"refs/tags/v4","object":{"sha":%q}}`, checkoutSHA)
+	case "/repos/actions/setup-go/commits/main":
+		fmt.Fprintf(w, `{"sha":%q}`, setupGoSHA)
+	case "/repos/acme/missing":
+		fmt.Fprint(w, `{"name":"missing"}`)
+	case "/repos/acme/limited/git/ref/tags/v1":
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "4102444800")
+		http.Error(w, `{"message":"API rate limit exceeded"}`, http.StatusForbidden)
+	case "/repos/acme/denied/git/ref/tags/v1":
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+	default:
+		notFound()
+	}
+}
+
+func newTestFreezer(srv *httptest.Server, opts ...Option) *Freezer {
+	return New(append([]Option{WithHost(srv.URL + "/api/v3/")}, opts...)...)
+}
+
+func TestScan(t *testing.T) {
+	_, srv := newFakeAPI(t)
+	refs, err := newTestFreezer(srv).Scan(context.Background(), fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, r := range refs {
+		got = append(got, fmt.Sprintf("%s:%d %s pinned=%v", r.Path, r.Line, r.Action(), r.Pinned))
+	}
+	want := []string{
+		".forgejo/workflows/deploy.yml:4 actions/checkout@v4 pinned=false",
+		".github/workflows/ci.yml:6 actions/checkout@v4 pinned=false",
+		".github/workflows/ci.yml:7 actions/setup-go@main pinned=false",
+		".github/workflows/ci.yml:8 actions/checkout@v4 pinned=false",
+		".github/workflows/ci.yml:10 actions/cache@" + checkoutSHA + " pinned=true",
+		".github/workflows/lint.yaml:5 acme/missing@v1 pinned=false",
+		".github/workflows/lint.yaml:6 acme/hidden@v1 pinned=false",
+		".github/workflows/sub/reusable.yml:4 actions/setup-go@main pinned=false",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Scan =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestScanWorkflowDirs(t *testing.T) {
+	_, srv := newFakeAPI(t)
+	ctx := context.Background()
+
+	refs, err := newTestFreezer(srv, WithWorkflowDirs(".forgejo/workflows")).Scan(ctx, fixture)
+	if err != nil || len(refs) != 1 {
+		t.Errorf("Scan(.forgejo/workflows) = %d refs, %v; want 1", len(refs), err)
+	}
+
+	if _, err := newTestFreezer(srv, WithWorkflowDirs("missing")).Scan(ctx, fixture); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Scan(missing) error = %v, want fs.ErrNotExist", err)
+	}
+	if _, err := newTestFreezer(srv).Scan(ctx, fstest.MapFS{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Scan(empty) error = %v, want fs.ErrNotExist", err)
+	}
+
+	invalid := fstest.MapFS{".github/workflows/bad.yml": {Data: []byte("jobs: [")}}
+	if _, err := newTestFreezer(srv).Scan(ctx, invalid); err == nil || !strings.Contains(err.Error(), "bad.yml") {
+		t.Errorf("Scan(invalid) error = %v, want one naming bad.yml", err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	api, srv := newFakeAPI(t)
+	refs := []Reference{
+		{Owner: "actions", Repo: "checkout", Ref: "v4"},
+		{Owner: "actions", Repo: "checkout", Ref: "v4", Line: 2},
+		{Owner: "actions", Repo: "setup-go", Ref: "main"},
+		{Owner: "actions", Repo: "cache", Ref: checkoutSHA, Pinned: true},
+		{Owner: "acme", Repo: "missing", Ref: "v1"},
+		{Owner: "acme", Repo: "hidden", Ref: "v1"},
+		{Owner: "acme", Repo: "denied", Ref: "v1"},
+		// Last: once rate limited, the client fails fast until the reset.
+		{Owner: "acme", Repo: "limited", Ref: "v1"},
+	}
+
+	res, err := newTestFreezer(srv, WithConcurrency(1)).Resolve(context.Background(), refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != len(refs) {
+		t.Fatalf("got %d resolutions for %d references", len(res), len(refs))
+	}
+
+	want := []struct {
+		sha    string
+		reason Reason
+	}{
+		{checkoutSHA, ""},
+		{checkoutSHA, ""},
+		{setupGoSHA, ""},
+		{checkoutSHA, ""},
+		{"", ReasonNotFound},
+		{"", ReasonPrivate},
+		{"", ReasonPermission},
+		{"", ReasonRateLimit},
+	}
+	for i, w := range want {
+		r := res[i]
+		if r.Reference != refs[i] || r.SHA != w.sha || r.Reason != w.reason || (r.Err != nil) != (w.reason != "") {
+			t.Errorf("%s: got SHA %q, reason %q, err %v; want %q, %q", refs[i].Action(), r.SHA, r.Reason, r.Err, w.sha, w.reason)
+		}
+	}
+
+	if n := api.calls["/repos/actions/checkout/git/ref/tags/v4"]; n != 1 {
+		t.Errorf("duplicate reference resolved %d times, want once", n)
+	}
+	if n := api.calls["/repos/actions/cache/git/ref/tags/"+checkoutSHA]; n != 0 {
+		t.Errorf("pinned reference was resolved")
+	}
+}
+
+func TestResolveUsesCache(t *testing.T) {
+	api, srv := newFakeAPI(t)
+	cache := NewMemoryCache()
+	refs := []Reference{{Owner: "actions", Repo: "checkout", Ref: "v4"}}
+
+	for range 2 {
+		res, err := newTestFreezer(srv, WithCache(cache)).Resolve(context.Background(), refs)
+		if err != nil || res[0].SHA != checkoutSHA {
+			t.Fatalf("Resolve = %+v, %v", res, err)
+		}
+	}
+	if n := api.calls["/repos/actions/checkout/git/ref/tags/v4"]; n != 1 {
+		t.Errorf("resolved %d times with a shared cache, want once", n)
+	}
+}
+
+func TestResolveCanceled(t *testing.T) {
+	_, srv := newFakeAPI(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := newTestFreezer(srv).Resolve(ctx, []Reference{{Owner: "actions", Repo: "checkout", Ref: "v4"}}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Resolve error = %v, want context.Canceled", err)
+	}
+}
+
+func TestPlanAndApply(t *testing.T) {
+	_, srv := newFakeAPI(t)
+	f := newTestFreezer(srv)
+	ctx := context.Background()
+
+	refs, err := f.Scan(ctx, fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := f.Resolve(ctx, refs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits, err := f.Plan(ctx, fixture, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, e := range edits {
+		paths = append(paths, e.Path)
+	}
+	if want := ".forgejo/workflows/deploy.yml .github/workflows/ci.yml .github/workflows/sub/reusable.yml"; strings.Join(paths, " ") != want {
+		t.Errorf("edited %v, want %s (lint.yaml only has failures)", paths, want)
+	}
+
+	written := make(map[string]string)
+	err = f.Apply(ctx, WriterFunc(func(path string, data []byte) error {
+		written[path] = string(data)
+		return nil
+	}), edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ci := written[".github/workflows/ci.yml"]
+	for _, want := range []string{
+		"      - uses: actions/checkout@" + checkoutSHA + " # v4\n      - uses: actions/setup-go@" + setupGoSHA + " # main\n      - uses: actions/checkout@" + checkoutSHA + " # v4\n",
+		"      - uses: ./local-action\n",
+		"      - uses: actions/cache@" + checkoutSHA + " # v4\n",
+	} {
+		if !strings.Contains(ci, want) {
+			t.Errorf("ci.yml does not contain %q:\n%s", want, ci)
+		}
+	}
+
+	if err := f.Apply(ctx, nil, edits); err == nil {
+		t.Error("Apply(nil Writer) succeeded")
+	}
+	if err := f.Apply(ctx, WriterFunc(func(string, []byte) error { return nil }), []Edit{{Path: "../escape.yml"}}); err == nil {
+		t.Error("Apply accepted a path outside the filesystem")
+	}
+}
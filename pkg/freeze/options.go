@@ -0,0 +1,125 @@
+package freeze
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultConcurrency is the number of references resolved in parallel
+// unless WithConcurrency says otherwise.
+const DefaultConcurrency = 8
+
+type Option func(*options)
+
+type options struct {
+	token        string
+	httpClient   *http.Client
+	baseURL      string
+	cache        Cache
+	concurrency  int
+	giteaHost    string
+	workflowDirs []string
+	gitRefs      bool
+	gitURL       string
+}
+
+// WithToken authenticates API requests with a GitHub token.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests, e.g. one whose
+// transport authenticates as a GitHub App.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// WithHost resolves against a GitHub Enterprise Server. host is either a
+// hostname such as "github.example.com", whose API is served under
+// /api/v3/, or the full API base URL.
+func WithHost(host string) Option {
+	return func(o *options) {
+		switch {
+		case host == "" || host == "github.com":
+			o.baseURL = ""
+		case strings.Contains(host, "://"):
+			o.baseURL = host
+		default:
+			o.baseURL = "https://" + host + "/api/v3/"
+		}
+	}
+}
+
+// WithGitea resolves against the REST API of a Gitea or Forgejo instance
+// instead of GitHub. host is either a hostname or a base URL such as
+// "https://codeberg.org". The token set by WithToken is sent to it.
+func WithGitea(host string) Option {
+	return func(o *options) {
+		o.giteaHost = host
+	}
+}
+
+// WithWorkflowDirs sets the slash-separated directories Scan looks in.
+func WithWorkflowDirs(dirs ...string) Option {
+	return func(o *options) {
+		o.workflowDirs = dirs
+	}
+}
+
+// WithGitRefs resolves tags and branches by listing the repository's refs
+// over the git protocol, like git ls-remote, which does not count against
+// the API rate limit. Commit SHAs and anything that fails are resolved
+// through the API as usual. baseURL is where repositories are served, e.g.
+// "https://github.com"; when empty it follows WithHost or WithGitea.
+func WithGitRefs(baseURL string) Option {
+	return func(o *options) {
+		o.gitRefs = true
+		o.gitURL = baseURL
+	}
+}
+
+// WithCache shares resolutions between Freezers or calls. By default each
+// Freezer has its own in-memory cache.
+func WithCache(cache Cache) Option {
+	return func(o *options) {
+		o.cache = cache
+	}
+}
+
+// WithConcurrency limits how many references are resolved in parallel.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = n
+	}
+}
+
+// gitBaseURL is where WithGitRefs lists refs: its own URL, or the web root of
+// the Gitea or GitHub Enterprise Server host.
+func gitBaseURL(o options) string {
+	base := o.gitURL
+	if base == "" {
+		base = o.giteaHost
+	}
+	if base == "" {
+		base = o.baseURL
+	}
+	if base == "" {
+		return ""
+	}
+	if !strings.Contains(base, "://") {
+		return "https://" + base
+	}
+	if o.gitURL != "" {
+		return base
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	return u.Scheme + "://" + u.Host
+}
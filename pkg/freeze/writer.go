@@ -0,0 +1,41 @@
+package freeze
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Writer receives the new content of edited files. Paths are slash-separated
+// and relative, as in fs.FS.
+type Writer interface {
+	WriteFile(path string, data []byte) error
+}
+
+// WriterFunc adapts a function to Writer, e.g. to collect edits in memory.
+type WriterFunc func(path string, data []byte) error
+
+func (f WriterFunc) WriteFile(path string, data []byte) error {
+	return f(path, data)
+}
+
+// DirWriter writes files under root on disk, keeping the mode of files that
+// already exist. It pairs with os.DirFS(root).
+func DirWriter(root string) Writer {
+	return dirWriter(root)
+}
+
+type dirWriter string
+
+func (d dirWriter) WriteFile(path string, data []byte) error {
+	if !fs.ValidPath(path) {
+		return &fs.PathError{Op: "write", Path: path, Err: fs.ErrInvalid}
+	}
+
+	full := filepath.Join(string(d), filepath.FromSlash(path))
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(full); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(full, data, mode)
+}